@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
 	goflag "flag"
+	"fmt"
+	"os"
 
 	"github.com/oauth2-proxy/tools/reference-gen/pkg/generator"
 	flag "github.com/spf13/pflag"
@@ -9,11 +12,33 @@ import (
 )
 
 var (
-	packageName   = flag.String("package", "", "api directory (or import path), for the package for which references should be generated")
-	requiredTypes = flag.StringSlice("types", []string{}, "types from the package for which references should be generated")
-	templateDir   = flag.String("template-dir", "", "path to output templates dir, if unset uses default templates")
+	packageNames  = flag.StringSlice("package", []string{}, "api directories, import paths or patterns (such as ./pkg/apis/...), for the packages for which references should be generated")
+	requiredTypes = flag.StringSlice("types", []string{}, "types from the packages for which references should be generated, types may be qualified with their package name or import path (<package>.<type>)")
+	templateDir   = flag.String("template-dir", "", "path to a directory of templates (*.tpl) that override the default templates of the same name")
 	headerFile    = flag.String("header-file", "", "file including header text to prepend to generated data")
 	outputFile    = flag.String("out-file", "", "path to output file to save the result")
+	outputDir     = flag.String("out-dir", "", "directory to write a file for each type to, along with an index of the types, instead of a single output file")
+	keyIndexFile  = flag.String("key-index-file", "", "file to write an index of every configuration key, as the path from the root types, to")
+	omitEmpty     = flag.Bool("omitempty-optional", false, "document fields with the omitempty option in their json or yaml tag as optional")
+	tagPriority   = flag.StringSlice("tag-priority", []string{"json", "yaml"}, "struct tags to name and embed fields by, in order of priority, fields are documented by the first of the tags they have, e.g. mapstructure,toml,json")
+	warnTags      = flag.Bool("warn-tag-mismatch", false, "warn about documented fields whose struct tags in the tag priority name them differently")
+	toc           = flag.Bool("toc", false, "add a table of contents, listing the types grouped by the root types they are reachable from, to the start of the output")
+	outputFormat  = flag.String("output-format", generator.OutputFormatMarkdown, "format of the generated output, one of: markdown, mdx, jsonschema")
+	check         = flag.Bool("check", false, "check that the output file is up to date instead of writing it, exits non-zero with a diff when it is stale")
+	inject        = flag.Bool("inject", false, "inject the generated content between the begin and end markers of the existing output file")
+	beginMarker   = flag.String("begin-marker", generator.DefaultBeginMarker, "marker after which generated content is injected when using --inject")
+	buildTags     = flag.StringSlice("build-tags", []string{}, "build tags used when loading the package")
+	endMarker     = flag.String("end-marker", generator.DefaultEndMarker, "marker before which generated content is injected when using --inject")
+	externalLinks = flag.StringArray("external-link", []string{}, "link types from packages outside of the loaded packages to their documentation, in the form <package pattern>=<url pattern>, e.g. k8s.io/apimachinery/*=https://pkg.go.dev/{{path}}#{{name}}")
+	linksFile     = flag.String("external-links-file", "", "YAML file mapping package patterns to url patterns for linking external types")
+	commonTypes   = flag.StringArray("common-type", []string{}, "display a type by another name, in the form <package path>.<type>=<name>, e.g. net/url.URL=string (URL)")
+	commonFile    = flag.String("common-types-file", "", "YAML file mapping types, in the form <package path>.<type>, to the name, description and link they are displayed with")
+	defaultsFuncs = flag.StringArray("defaults-func", []string{}, "function returning a composite literal of the defaults of a type, in the form <function> or <package path>.<function>")
+	minStability  = flag.String("min-stability", generator.StabilityAlpha, "minimum stability of the types and fields to document, one of: alpha, beta, stable")
+	audiences     = flag.StringSlice("audience", []string{}, "audiences, set by +reference-gen:audience markers, to document in addition to types and fields without an audience, e.g. internal")
+	profile       = flag.String("profile", "", "adapt the output for a documentation tool, one of: docusaurus")
+	frontMatter   = flag.StringArray("front-matter", []string{}, "field to add to the front matter of the document, in the form <key>=<value>, e.g. sidebar_position=3")
+	defaultTag    = flag.String("default-tag", generator.DefaultDefaultTag, "struct tag to read the default values of fields from when they have no +reference-gen:default marker, set to empty to only use markers")
 )
 
 func main() {
@@ -24,13 +49,63 @@ func main() {
 	}
 	flag.Parse()
 
-	gen, err := generator.NewGenerator(*packageName, *requiredTypes, *headerFile, *outputFile, *templateDir)
+	opts := []generator.Option{
+		generator.WithOutputFormat(*outputFormat),
+		generator.WithCheckOnly(*check),
+		generator.WithBuildTags(*buildTags),
+		generator.WithExternalLinksFile(*linksFile),
+		generator.WithExternalLinks(*externalLinks),
+		generator.WithCommonTypesFile(*commonFile),
+		generator.WithCommonTypes(*commonTypes),
+		generator.WithDefaultTag(*defaultTag),
+		generator.WithDefaultsFuncs(*defaultsFuncs),
+		generator.WithMinStability(*minStability),
+		generator.WithAudiences(*audiences),
+		generator.WithProfile(*profile),
+		generator.WithFrontMatter(*frontMatter),
+		generator.WithOutputDirectory(*outputDir),
+		generator.WithTableOfContents(*toc),
+		generator.WithKeyIndexFile(*keyIndexFile),
+		generator.WithOmitEmptyOptional(*omitEmpty),
+		generator.WithTagPriority(*tagPriority),
+		generator.WithTagMismatchWarnings(*warnTags),
+	}
+	if *inject {
+		opts = append(opts, generator.WithInjectMarkers(*beginMarker, *endMarker))
+	}
+
+	gen, err := generator.NewGenerator(*packageNames, *requiredTypes, *headerFile, *outputFile, *templateDir, opts...)
 	if err != nil {
 		klog.Fatalf("error constructing generator: %v", err)
 	}
 
-	klog.Infof("Running generator on package %q", *packageName)
+	klog.Infof("Running generator on packages %q", *packageNames)
 	if err := gen.Run(); err != nil {
+		if stale := staleOutputs(err); len(stale) > 0 {
+			for _, staleErr := range stale {
+				fmt.Fprint(os.Stderr, staleErr.Diff)
+				klog.Errorf("%v", staleErr)
+			}
+			klog.Flush()
+			os.Exit(1)
+		}
 		klog.Fatalf("error running generator: %v", err)
 	}
 }
+
+// staleOutputs returns the stale outputs reported by the error, which joins
+// the errors of the reference and the key index when both are checked.
+func staleOutputs(err error) []*generator.StaleOutputError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var out []*generator.StaleOutputError
+		for _, e := range joined.Unwrap() {
+			out = append(out, staleOutputs(e)...)
+		}
+		return out
+	}
+	var staleErr *generator.StaleOutputError
+	if errors.As(err, &staleErr) {
+		return []*generator.StaleOutputError{staleErr}
+	}
+	return nil
+}
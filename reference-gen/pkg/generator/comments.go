@@ -0,0 +1,226 @@
+package generator
+
+import (
+	"go/doc"
+	"go/doc/comment"
+	"strings"
+
+	"k8s.io/gengo/v2/types"
+)
+
+// commentRenderer renders Go doc comments as Markdown.
+// Comments are parsed with go/doc/comment, so that paragraphs are reflowed and
+// lists, code blocks and headings are kept. Doc links, such as [Options],
+// link to the section of a documented type, or to the external documentation
+// of types from other packages. Text is escaped for the output format.
+type commentRenderer struct {
+	knownTypes *typeIndex
+	links      externalLinks
+	escaper    escaper
+	// packages maps the names of the loaded packages to their paths.
+	packages map[string]string
+	// typesByName holds the documented types with each name, sorted, to
+	// resolve doc links.
+	typesByName map[string][]*types.Type
+}
+
+func newCommentRenderer(knownTypes *typeIndex, links externalLinks, escaper escaper, pkgs []*types.Package) *commentRenderer {
+	r := &commentRenderer{
+		knownTypes:  knownTypes,
+		links:       links,
+		escaper:     escaper,
+		packages:    make(map[string]string),
+		typesByName: make(map[string][]*types.Type),
+	}
+	for _, pkg := range pkgs {
+		r.packages[pkg.Name] = pkg.Path
+	}
+	for _, t := range sortTypes(knownTypes.types.toList()) {
+		r.typesByName[t.Name.Name] = append(r.typesByName[t.Name.Name], t)
+	}
+	return r
+}
+
+// parse parses the comments, without their markers and Deprecated paragraph.
+func (r *commentRenderer) parse(commentLines []string) *comment.Doc {
+	lines := filterDeprecatedParagraph(filterCommentTags(commentLines))
+	p := &comment.Parser{
+		LookupPackage: func(name string) (string, bool) {
+			path, ok := r.packages[name]
+			return path, ok
+		},
+		LookupSym: func(recv, name string) bool {
+			if recv != "" {
+				// Links to fields and methods link to the type.
+				name = recv
+			}
+			return r.findType("", name) != nil
+		},
+	}
+	return p.Parse(strings.Join(lines, "\n"))
+}
+
+// markdown renders the comments as Markdown blocks, for use as the
+// description of a type.
+func (r *commentRenderer) markdown(commentLines []string) string {
+	var blocks []string
+	for _, block := range r.parse(commentLines).Content {
+		switch b := block.(type) {
+		case *comment.Paragraph:
+			blocks = append(blocks, r.text(b.Text, r.escaper.text))
+		case *comment.Heading:
+			// Types are rendered under a level 3 heading.
+			blocks = append(blocks, "#### "+r.text(b.Text, r.escaper.text))
+		case *comment.Code:
+			fence := codeFence(b.Text)
+			blocks = append(blocks, fence+"\n"+b.Text+fence)
+		case *comment.List:
+			sep := "\n"
+			if b.BlankBetween() {
+				sep = "\n\n"
+			}
+			var items []string
+			for _, item := range b.Items {
+				items = append(items, listMarker(item)+" "+r.itemText(item, " ", r.escaper.text))
+			}
+			blocks = append(blocks, strings.Join(items, sep))
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// tableCell renders the comments as Markdown that fits on a single line, for
+// use in a table cell.
+func (r *commentRenderer) tableCell(commentLines []string) string {
+	var blocks []string
+	for _, block := range r.parse(commentLines).Content {
+		switch b := block.(type) {
+		case *comment.Paragraph:
+			blocks = append(blocks, r.text(b.Text, r.escaper.cell))
+		case *comment.Heading:
+			blocks = append(blocks, "**"+r.text(b.Text, r.escaper.cell)+"**")
+		case *comment.Code:
+			code := r.escaper.code(strings.TrimSuffix(b.Text, "\n"))
+			blocks = append(blocks, "<pre>"+strings.ReplaceAll(code, "\n", "<br/>")+"</pre>")
+		case *comment.List:
+			tag := "ul"
+			if b.Items[0].Number != "" {
+				tag = "ol"
+			}
+			var items strings.Builder
+			for _, item := range b.Items {
+				items.WriteString("<li>" + r.itemText(item, "<br/>", r.escaper.cell) + "</li>")
+			}
+			blocks = append(blocks, "<"+tag+">"+items.String()+"</"+tag+">")
+		}
+	}
+	return strings.Join(blocks, "<br/><br/>")
+}
+
+// synopsis renders the first sentence of the comments as plain text, for use
+// in a table cell.
+func (r *commentRenderer) synopsis(commentLines []string) string {
+	lines := filterDeprecatedParagraph(filterCommentTags(commentLines))
+	var pkg doc.Package
+	return r.escaper.cell(pkg.Synopsis(strings.Join(lines, "\n")))
+}
+
+// itemText renders the paragraphs of a list item, joined by the separator.
+func (r *commentRenderer) itemText(item *comment.ListItem, sep string, escape func(string) string) string {
+	var paragraphs []string
+	for _, block := range item.Content {
+		if p, ok := block.(*comment.Paragraph); ok {
+			paragraphs = append(paragraphs, r.text(p.Text, escape))
+		}
+	}
+	return strings.Join(paragraphs, sep)
+}
+
+// text renders inline text on a single line, reflowing wrapped lines.
+// Plain text is escaped with the escape function given.
+func (r *commentRenderer) text(text []comment.Text, escape func(string) string) string {
+	var b strings.Builder
+	for _, t := range text {
+		switch t := t.(type) {
+		case comment.Plain:
+			b.WriteString(escape(strings.ReplaceAll(string(t), "\n", " ")))
+		case comment.Italic:
+			b.WriteString("_" + escape(strings.ReplaceAll(string(t), "\n", " ")) + "_")
+		case *comment.Link:
+			if t.Auto {
+				b.WriteString(t.URL)
+				continue
+			}
+			b.WriteString("[" + r.text(t.Text, escape) + "](" + t.URL + ")")
+		case *comment.DocLink:
+			if url := r.docLinkURL(t); url != "" {
+				b.WriteString("[" + r.text(t.Text, escape) + "](" + url + ")")
+				continue
+			}
+			b.WriteString(r.text(t.Text, escape))
+		}
+	}
+	return b.String()
+}
+
+// docLinkURL resolves the doc link to the section of a documented type, or to
+// the external documentation of the type.
+func (r *commentRenderer) docLinkURL(link *comment.DocLink) string {
+	name := link.Name
+	if link.Recv != "" {
+		name = link.Recv
+	}
+	if name == "" {
+		// Links to packages have no section to link to.
+		return ""
+	}
+
+	if t := r.findType(link.ImportPath, name); t != nil {
+		return r.knownTypes.link(t)
+	}
+	if link.ImportPath == "" {
+		return ""
+	}
+
+	linkName := link.Name
+	if link.Recv != "" {
+		linkName = link.Recv + "." + link.Name
+	}
+	return r.links.linkFor(&types.Type{Name: types.Name{Package: link.ImportPath, Name: linkName}})
+}
+
+// findType finds the documented type with the name, from the package if one
+// is given. Types from any package match when no package is given, as the
+// package of the comment is not known.
+func (r *commentRenderer) findType(pkgPath, name string) *types.Type {
+	for _, t := range r.typesByName[name] {
+		if pkgPath == "" || t.Name.Package == pkgPath {
+			return t
+		}
+	}
+	return nil
+}
+
+// codeFence returns the fence of a Markdown code block of the text, which is
+// longer than any run of backticks in the text so that the text cannot end
+// the block.
+func codeFence(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// listMarker returns the Markdown marker of the list item.
+func listMarker(item *comment.ListItem) string {
+	if item.Number != "" {
+		return item.Number + "."
+	}
+	return "-"
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/gengo/v2/types"
+)
+
+// commonType overrides how a type is displayed in the references.
+// This is useful for types that have a custom marshalling rule, but that
+// belong to packages where +reference-gen:alias-name cannot be added.
+type commonType struct {
+	// Name is displayed in place of the Go type.
+	Name string `yaml:"name"`
+	// Description is added to the description of fields using the type.
+	Description string `yaml:"description"`
+	// Link is an optional link for the type, in place of the external link.
+	Link string `yaml:"link"`
+}
+
+// UnmarshalYAML allows a common type to be given as just its name.
+func (c *commonType) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&c.Name)
+	}
+
+	type plain commonType
+	return value.Decode((*plain)(c))
+}
+
+// commonTypes maps the identifiers of types, in the form <package path>.<type>,
+// to the common type they are displayed as.
+type commonTypes map[string]commonType
+
+func defaultCommonTypes() commonTypes {
+	return commonTypes{
+		"time.Duration": {Name: "duration"},
+	}
+}
+
+// add adds an override in the form <package path>.<type>=<name>.
+func (c commonTypes) add(override string) error {
+	identifier, name, ok := strings.Cut(override, "=")
+	identifier, name = strings.TrimSpace(identifier), strings.TrimSpace(name)
+	if !ok || identifier == "" || name == "" {
+		return fmt.Errorf("invalid common type %q, expected <package path>.<type>=<name>", override)
+	}
+	c[identifier] = commonType{Name: name}
+	return nil
+}
+
+// loadFile adds the overrides from a YAML file mapping type identifiers to
+// either a name, or a name, description and link.
+func (c commonTypes) loadFile(fileName string) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+
+	overrides := make(map[string]commonType)
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return fmt.Errorf("error parsing file %q: %v", fileName, err)
+	}
+	for identifier, override := range overrides {
+		if override.Name == "" {
+			return fmt.Errorf("common type %q in file %q has no name", identifier, fileName)
+		}
+		c[identifier] = override
+	}
+	return nil
+}
+
+// lookup returns the common type for the type, if there is one.
+func (c commonTypes) lookup(t *types.Type) (commonType, bool) {
+	common, ok := c[typeIdentifier(t)]
+	return common, ok
+}
@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	gengo "k8s.io/gengo/v2"
+	"k8s.io/gengo/v2/types"
+)
+
+const (
+	// DefaultDefaultTag is the struct tag that default values are read from,
+	// as used by github.com/creasty/defaults.
+	DefaultDefaultTag = "default"
+
+	defaultValueMarker = "reference-gen:default"
+)
+
+// memberDefaults looks up the default values of members.
+// A +reference-gen:default=<value> marker takes priority over the literals
+// returned by the defaults functions, which take priority over the struct tag.
+type memberDefaults struct {
+	// tag is the struct tag to read default values from, when not empty.
+	tag string
+	// funcs are the functions whose returned literals are read as defaults,
+	// see readDefaultsFuncs.
+	funcs []string
+	// literals are the values read from the defaults functions, keyed by the
+	// type of the literal and the names of the fields from it.
+	literals map[string]string
+}
+
+// value returns the default value of the member, or an empty string if the
+// member has no default. Values from the defaults functions depend on the
+// fields through which the member is reached, see valueAt.
+func (d memberDefaults) value(m types.Member) string {
+	return d.valueAt(m, nil)
+}
+
+// valueAt returns the default value of the member when reached through the
+// fields given by the keys, such as <package path>.Options.Cookie for the
+// Name member of a Cookie. Keys are given from the most specific, starting
+// from the root of the configuration, to the least specific, starting from
+// the type of the member.
+func (d memberDefaults) valueAt(m types.Member, keys []string) string {
+	tags := gengo.ExtractCommentTags("+", m.CommentLines)
+	if values, ok := tags[defaultValueMarker]; ok {
+		// There should only be one entry
+		return values[0]
+	}
+
+	for _, key := range keys {
+		if value, ok := d.literals[key+"."+m.Name]; ok {
+			return value
+		}
+	}
+
+	if d.tag == "" {
+		return ""
+	}
+	return reflect.StructTag(m.Tags).Get(d.tag)
+}
+
+// pathDefault is the default value of a member at one of its config paths.
+type pathDefault struct {
+	Path  string
+	Value string
+}
+
+// showDefaultColumnFunc constructs a showDefaultColumn function for the template.
+// The column is shown for every type when any documented member has a default,
+// so that the tables within a document are consistent.
+func showDefaultColumnFunc(members *memberIndex, typeList []*types.Type) func() bool {
+	show := members.anyDefaults(typeList)
+	return func() bool {
+		return show
+	}
+}
+
+// schemaDefault converts the default value to a JSON value for the schema.
+// Values for string schemas may be quoted, other values are parsed as JSON
+// and are kept as a string when they cannot be parsed.
+func schemaDefault(value string, s *jsonSchema) interface{} {
+	if s.Type == "string" {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return value
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(value), &v); err == nil {
+		return v
+	}
+	return value
+}
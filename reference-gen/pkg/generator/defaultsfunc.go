@@ -0,0 +1,304 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/printer"
+	"go/token"
+	gotypes "go/types"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+	gengo "k8s.io/gengo/v2"
+	"k8s.io/gengo/v2/types"
+)
+
+const defaultsFuncMarker = "reference-gen:defaults-func"
+
+// defaultsFunc identifies a function that returns the defaults of a type.
+type defaultsFunc struct {
+	pkgPath string
+	name    string
+}
+
+func (f defaultsFunc) String() string {
+	return f.pkgPath + "." + f.name
+}
+
+// readDefaultsFuncs reads the composite literals returned by the defaults
+// functions and returns the value of each element of the literals. Functions
+// are given by name, optionally qualified with their package path, or by a
+// +reference-gen:defaults-func=<function> marker on a type.
+//
+// Values are keyed by the type constructed by the returned literal and the
+// names of the fields from it, such as <package path>.Options.Cookie.Name, so
+// that a type used in several fields can have a different default in each.
+// The functions are read from the syntax of the loaded packages, keyed by
+// their path.
+func readDefaultsFuncs(pkgs []*types.Package, syntax map[string]*packages.Package, funcNames []string) (map[string]string, error) {
+	funcs, err := findDefaultsFuncs(pkgs, funcNames)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &defaultsAttributor{
+		values: make(map[string]string),
+	}
+	for _, f := range funcs {
+		pkg, ok := syntax[f.pkgPath]
+		if !ok {
+			return nil, fmt.Errorf("defaults function %q: package not loaded", f)
+		}
+		fn, lit, err := returnedLiteral(pkg, f.name)
+		if err != nil {
+			return nil, fmt.Errorf("defaults function %q: %v", f, err)
+		}
+		name, ok := literalTypeName(pkg.TypesInfo, lit)
+		if !ok {
+			return nil, fmt.Errorf("defaults function %q: function does not return a struct of a named type", f)
+		}
+		a.fset, a.info, a.body = pkg.Fset, pkg.TypesInfo, fn.Body
+		a.attribute(lit, name)
+	}
+	return a.values, nil
+}
+
+// findDefaultsFuncs resolves the function names given, and those set by
+// markers on the types of the packages.
+func findDefaultsFuncs(pkgs []*types.Package, funcNames []string) ([]defaultsFunc, error) {
+	var funcs []defaultsFunc
+	for _, name := range funcNames {
+		f, err := resolveDefaultsFunc(pkgs, name)
+		if err != nil {
+			return nil, err
+		}
+		funcs = append(funcs, f)
+	}
+
+	for _, pkg := range pkgs {
+		for _, t := range sortTypes(newTypeSetFromStringMap(pkg.Types).toList()) {
+			tags := gengo.ExtractCommentTags("+", t.CommentLines)
+			if names, ok := tags[defaultsFuncMarker]; ok {
+				// There should only be one entry
+				funcs = append(funcs, defaultsFunc{pkgPath: pkg.Path, name: names[0]})
+			}
+		}
+	}
+	return funcs, nil
+}
+
+// resolveDefaultsFunc finds the package of a function given as either
+// <function> or <package path>.<function>.
+func resolveDefaultsFunc(pkgs []*types.Package, name string) (defaultsFunc, error) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return defaultsFunc{pkgPath: name[:i], name: name[i+1:]}, nil
+	}
+
+	var found []defaultsFunc
+	for _, pkg := range pkgs {
+		if _, ok := pkg.Functions[name]; ok {
+			found = append(found, defaultsFunc{pkgPath: pkg.Path, name: name})
+		}
+	}
+	switch len(found) {
+	case 0:
+		return defaultsFunc{}, fmt.Errorf("defaults function %q not found in the loaded packages", name)
+	case 1:
+		return found[0], nil
+	default:
+		return defaultsFunc{}, fmt.Errorf("defaults function %q found in %d packages, qualify it with its package path", name, len(found))
+	}
+}
+
+// returnedLiteral finds the composite literal returned by the function.
+// The function may return the literal, a pointer to it, or a local variable
+// that is initialised with the literal.
+func returnedLiteral(pkg *packages.Package, name string) (*ast.FuncDecl, *ast.CompositeLit, error) {
+	var fn *ast.FuncDecl
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			if d, ok := decl.(*ast.FuncDecl); ok && d.Recv == nil && d.Name.Name == name {
+				fn = d
+			}
+		}
+	}
+	if fn == nil || fn.Body == nil {
+		return nil, nil, fmt.Errorf("function not found")
+	}
+
+	var lit *ast.CompositeLit
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Returns from nested functions do not return from this function.
+			return false
+		case *ast.ReturnStmt:
+			if lit == nil && len(n.Results) == 1 {
+				lit = resolveLiteral(fn.Body, pkg.TypesInfo, n.Results[0])
+			}
+		}
+		return lit == nil
+	})
+	if lit == nil {
+		return nil, nil, fmt.Errorf("function does not return a composite literal")
+	}
+	return fn, lit, nil
+}
+
+// resolveLiteral unwraps the expression to the composite literal it refers to,
+// or returns nil if it does not refer to a composite literal.
+func resolveLiteral(body *ast.BlockStmt, info *gotypes.Info, expr ast.Expr) *ast.CompositeLit {
+	switch e := expr.(type) {
+	case *ast.CompositeLit:
+		return e
+	case *ast.ParenExpr:
+		return resolveLiteral(body, info, e.X)
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return resolveLiteral(body, info, e.X)
+		}
+	case *ast.Ident:
+		obj := info.Uses[e]
+		if obj == nil {
+			return nil
+		}
+		var init ast.Expr
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				for i, lhs := range n.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok && info.Defs[id] == obj && len(n.Rhs) == len(n.Lhs) {
+						init = n.Rhs[i]
+					}
+				}
+			case *ast.ValueSpec:
+				for i, id := range n.Names {
+					if info.Defs[id] == obj && len(n.Values) == len(n.Names) {
+						init = n.Values[i]
+					}
+				}
+			}
+			return init == nil
+		})
+		if init != nil {
+			return resolveLiteral(body, info, init)
+		}
+	}
+	return nil
+}
+
+// literalTypeName returns the name of the named struct type constructed by the
+// composite literal, qualified with its package path.
+func literalTypeName(info *gotypes.Info, lit *ast.CompositeLit) (string, bool) {
+	tv, ok := info.Types[lit]
+	if !ok {
+		return "", false
+	}
+	named, ok := tv.Type.(*gotypes.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return "", false
+	}
+	if _, ok := named.Underlying().(*gotypes.Struct); !ok {
+		return "", false
+	}
+	return named.Obj().Pkg().Path() + "." + named.Obj().Name(), true
+}
+
+// defaultsAttributor attributes the values of composite literals to the
+// fields of the structs they construct.
+type defaultsAttributor struct {
+	// values are keyed by the type of the returned literal and the names of
+	// the fields from it.
+	values map[string]string
+
+	// fset, info and body are those of the defaults function being read.
+	fset *token.FileSet
+	info *gotypes.Info
+	body *ast.BlockStmt
+}
+
+// attribute records the values of the struct literal as the defaults of the
+// fields below the key given. Struct literals nested within the literal are
+// recorded below the key of their field.
+// Values already recorded, by an earlier function, are kept.
+func (a *defaultsAttributor) attribute(lit *ast.CompositeLit, key string) {
+	tv, ok := a.info.Types[lit]
+	if !ok {
+		return
+	}
+	st, ok := tv.Type.Underlying().(*gotypes.Struct)
+	if !ok {
+		return
+	}
+
+	for i, elt := range lit.Elts {
+		fieldName, value := "", elt
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if id, ok := kv.Key.(*ast.Ident); ok {
+				fieldName, value = id.Name, kv.Value
+			}
+		} else if i < st.NumFields() {
+			fieldName = st.Field(i).Name()
+		}
+		if fieldName == "" {
+			continue
+		}
+
+		fieldKey := key + "." + fieldName
+		if nested := resolveLiteral(a.body, a.info, value); nested != nil && isStructLiteral(a.info, nested) {
+			a.attribute(nested, fieldKey)
+			continue
+		}
+		if _, ok := a.values[fieldKey]; !ok {
+			a.values[fieldKey] = a.format(value)
+		}
+	}
+}
+
+// format renders the value of a literal element for the documentation.
+// Constant values are evaluated, other expressions are rendered as source.
+func (a *defaultsAttributor) format(expr ast.Expr) string {
+	if tv, ok := a.info.Types[expr]; ok && tv.Value != nil {
+		if isDurationType(tv.Type) {
+			if v, ok := constant.Int64Val(tv.Value); ok {
+				return time.Duration(v).String()
+			}
+		}
+		switch tv.Value.Kind() {
+		case constant.String:
+			return constant.StringVal(tv.Value)
+		case constant.Float:
+			v, _ := constant.Float64Val(tv.Value)
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		default:
+			return tv.Value.String()
+		}
+	}
+
+	var b strings.Builder
+	if err := printer.Fprint(&b, a.fset, expr); err != nil {
+		return ""
+	}
+	// Defaults are rendered on a single line, collapse any formatting of the
+	// expression.
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// isStructLiteral determines if the composite literal constructs a struct.
+func isStructLiteral(info *gotypes.Info, lit *ast.CompositeLit) bool {
+	tv, ok := info.Types[lit]
+	if !ok {
+		return false
+	}
+	_, ok = tv.Type.Underlying().(*gotypes.Struct)
+	return ok
+}
+
+// isDurationType determines if the type is time.Duration.
+func isDurationType(t gotypes.Type) bool {
+	named, ok := t.(*gotypes.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Duration"
+}
@@ -0,0 +1,177 @@
+package generator
+
+import (
+	"strings"
+
+	gengo "k8s.io/gengo/v2"
+	"k8s.io/gengo/v2/types"
+)
+
+const (
+	deprecatedMarker    = "reference-gen:deprecated"
+	deprecatedParagraph = "Deprecated:"
+)
+
+// deprecation describes why a type or member is deprecated.
+// Types and members are deprecated by a paragraph starting with "Deprecated:",
+// as recognised by Go tooling, or by a +reference-gen:deprecated marker, which
+// may name the replacement as +reference-gen:deprecated=<replacement>.
+type deprecation struct {
+	// Message is the text of the Deprecated paragraph, if any.
+	Message string
+	// Replacement is the type or option that replaces the deprecated one, if any.
+	Replacement string
+}
+
+// deprecationOf returns the deprecation described by the comments, or nil if
+// the comments do not deprecate the type or member.
+func deprecationOf(commentLines []string) *deprecation {
+	var d *deprecation
+
+	lines := filterCommentTags(commentLines)
+	start, end := deprecatedParagraphBounds(lines)
+	if start >= 0 {
+		var message []string
+		for _, line := range lines[start:end] {
+			message = append(message, strings.TrimSpace(line))
+		}
+		d = &deprecation{
+			Message: strings.TrimSpace(strings.TrimPrefix(strings.Join(message, " "), deprecatedParagraph)),
+		}
+	}
+
+	tags := gengo.ExtractCommentTags("+", commentLines)
+	if values, ok := tags[deprecatedMarker]; ok {
+		if d == nil {
+			d = &deprecation{}
+		}
+		// There should only be one entry
+		d.Replacement = strings.TrimSpace(values[0])
+	}
+	return d
+}
+
+// deprecatedParagraphBounds returns the first and last+1 line of the
+// Deprecated paragraph of the comments, or -1 if there is no such paragraph.
+func deprecatedParagraphBounds(commentLines []string) (int, int) {
+	for i, line := range commentLines {
+		if !strings.HasPrefix(strings.TrimSpace(line), deprecatedParagraph) {
+			continue
+		}
+		if i > 0 && strings.TrimSpace(commentLines[i-1]) != "" {
+			// Only a paragraph that starts with Deprecated: deprecates.
+			continue
+		}
+		end := i + 1
+		for end < len(commentLines) && strings.TrimSpace(commentLines[end]) != "" {
+			end++
+		}
+		return i, end
+	}
+	return -1, -1
+}
+
+// filterDeprecatedParagraph removes the Deprecated paragraph from the comments,
+// as it is rendered separately, along with any trailing blank lines.
+func filterDeprecatedParagraph(commentLines []string) []string {
+	start, end := deprecatedParagraphBounds(commentLines)
+	if start < 0 {
+		return commentLines
+	}
+
+	out := append(append([]string{}, commentLines[:start]...), commentLines[end:]...)
+	for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// deprecatedOption is a deprecated type, or deprecated member of a type, listed
+// in the summary of deprecated options.
+type deprecatedOption struct {
+	// Name is the name of the type, or the config path of the member. Members
+	// that cannot be reached from a root type are named by their type.
+	Name string
+	// Type is the deprecated type, or the type of the deprecated member.
+	Type *types.Type
+	// Member is the deprecated member, or nil if the type itself is deprecated.
+	Member *types.Member
+	// Deprecation describes why the option is deprecated.
+	Deprecation *deprecation
+}
+
+// deprecatedOptionsFunc constructs a deprecatedOptions function for the template
+func deprecatedOptionsFunc(knownTypes *typeIndex, members *memberIndex) func(typeList []*types.Type) []deprecatedOption {
+	return func(typeList []*types.Type) []deprecatedOption {
+		return deprecatedOptions(typeList, knownTypes, members)
+	}
+}
+
+// deprecatedOptions lists the deprecated visible types and members of the types.
+// Members are listed once for each config path that they can be set at.
+func deprecatedOptions(typeList []*types.Type, knownTypes *typeIndex, members *memberIndex) []deprecatedOption {
+	var out []deprecatedOption
+	for _, t := range visibleTypes(sortTypes(typeList)) {
+		name := knownTypes.name(t)
+		if d := deprecationOf(t.CommentLines); d != nil {
+			out = append(out, deprecatedOption{Name: name, Type: t, Deprecation: d})
+		}
+		for i := range t.Members {
+			m := &t.Members[i]
+			if hideMember(*m, members.priority) {
+				continue
+			}
+			d := deprecationOf(m.CommentLines)
+			if d == nil {
+				continue
+			}
+			paths := members.configPaths(m)
+			if len(paths) == 0 {
+				paths = []string{name + "." + fieldName(*m, t, members.priority)}
+			}
+			for _, path := range paths {
+				out = append(out, deprecatedOption{Name: path, Type: t, Member: m, Deprecation: d})
+			}
+		}
+	}
+	return out
+}
+
+// schemaDescription adds the deprecation to the description, as JSON Schema
+// can only mark a schema as deprecated.
+func (d *deprecation) schemaDescription(description string) string {
+	text := "Deprecated."
+	if d.Message != "" {
+		text = deprecatedParagraph + " " + d.Message
+	}
+	if d.Replacement != "" {
+		text += " Use " + d.Replacement + " instead."
+	}
+	if description == "" {
+		return text
+	}
+	return description + "\n\n" + text
+}
+
+// replacementLinkFunc constructs a replacementLink function for the template
+func replacementLinkFunc(knownTypes *typeIndex, members *memberIndex, e escaper) func(replacement string) string {
+	return func(replacement string) string {
+		return replacementLink(replacement, knownTypes, members, e)
+	}
+}
+
+// replacementLink links the replacement to the section of the type, or the
+// row of the member, that it names. Members are named by their type and field
+// name, such as Cookie.secret, or by their config path, such as cookie.secret.
+// Replacements that name neither are rendered as code.
+func replacementLink(replacement string, knownTypes *typeIndex, members *memberIndex, e escaper) string {
+	for t := range knownTypes.types {
+		if knownTypes.name(t) == replacement {
+			return "[" + e.cell(replacement) + "](" + knownTypes.link(t) + ")"
+		}
+	}
+	if m := members.lookup(replacement); m != nil {
+		return "[" + e.cell(backtick(replacement)) + "](" + members.link(m) + ")"
+	}
+	return e.cell(backtick(replacement))
+}
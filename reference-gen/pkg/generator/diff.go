@@ -0,0 +1,213 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines printed around each change.
+const diffContextLines = 3
+
+// diffOp is the operation of a line of a diff.
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffInsert
+	diffDelete
+)
+
+// diffLine is a single line of a line based diff.
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// unifiedDiff prints the diff from one text to another in the unified diff
+// format, as if it were a git diff.
+// Returns an empty string when the texts are equal.
+func unifiedDiff(fromName, toName, from, to string) string {
+	lines := diffLines(from, to)
+
+	// Record the line number in each file at the start of each diff line.
+	fromLine, toLine := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	fromLine[0], toLine[0] = 1, 1
+	var changes []int
+	for i, line := range lines {
+		fromLine[i+1], toLine[i+1] = fromLine[i], toLine[i]
+		if line.op != diffInsert {
+			fromLine[i+1]++
+		}
+		if line.op != diffDelete {
+			toLine[i+1]++
+		}
+		if line.op != diffEqual {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var buff bytes.Buffer
+	_, _ = fmt.Fprintf(&buff, "--- %s\n+++ %s\n", fromName, toName)
+
+	for len(changes) > 0 {
+		// Group changes that are close enough for their context to overlap.
+		last := 0
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*diffContextLines {
+			last++
+		}
+		start := max(changes[0]-diffContextLines, 0)
+		end := min(changes[last]+diffContextLines+1, len(lines))
+		changes = changes[last+1:]
+
+		_, _ = fmt.Fprintf(&buff, "@@ -%s +%s @@\n",
+			hunkRange(fromLine[start], fromLine[end]-fromLine[start]),
+			hunkRange(toLine[start], toLine[end]-toLine[start]),
+		)
+		for _, line := range lines[start:end] {
+			printDiffLine(&buff, line)
+		}
+	}
+
+	return buff.String()
+}
+
+// diffLines diffs the lines of the two texts with the algorithm of Myers, from
+// "An O(ND) Difference Algorithm and Its Variations", in its linear space
+// variation. It takes time in proportion to the length of the texts times the
+// number of differing lines, which are few for a stale output.
+func diffLines(from, to string) []diffLine {
+	return appendDiff(nil, splitLines(from), splitLines(to))
+}
+
+// appendDiff appends the diff of the lines to the diff given. The lines shared
+// by the start and end of both are matched first, then the lines between them
+// are split around the middle snake of their shortest edit script.
+func appendDiff(lines []diffLine, a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{op: diffEqual, text: text})
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(midA) == 0:
+		for _, text := range midB {
+			lines = append(lines, diffLine{op: diffInsert, text: text})
+		}
+	case len(midB) == 0:
+		for _, text := range midA {
+			lines = append(lines, diffLine{op: diffDelete, text: text})
+		}
+	default:
+		// Neither half of the texts around the snake holds all of their
+		// differences, as the texts differ in their first and last lines.
+		x, y, u, v := middleSnake(midA, midB)
+		lines = appendDiff(lines, midA[:x], midB[:y])
+		for _, text := range midA[x:u] {
+			lines = append(lines, diffLine{op: diffEqual, text: text})
+		}
+		lines = appendDiff(lines, midA[u:], midB[v:])
+	}
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{op: diffEqual, text: text})
+	}
+	return lines
+}
+
+// middleSnake finds the snake, a run of equal lines, in the middle of the
+// shortest edit script from a to b, as the lines from x to u of a and from y
+// to v of b. The script is searched from both ends at once, keeping only the
+// furthest point reached on each diagonal k = x - y, until the searches meet.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward holds the furthest x reached from the start on each diagonal,
+	// backward the furthest distance reached from the end, on the diagonals
+	// of the reversed texts.
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			x := forward[offset+k-1] + 1
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if back := delta - k; odd && back >= -(d-1) && back <= d-1 && x+backward[offset+back] >= n {
+				return startX, startY, x, y
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			x := backward[offset+k-1] + 1
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if front := delta - k; !odd && front >= -d && front <= d && forward[offset+front]+x >= n {
+				return n - x, m - y, n - startX, m - startY
+			}
+		}
+	}
+	// The searches always meet within half of the longest possible script.
+	return n, m, n, m
+}
+
+// splitLines splits the text into its lines, keeping the line endings.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// hunkRange formats the start and length of a hunk for the hunk header.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		// An empty range refers to the line before the hunk.
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
+
+// printDiffLine prints the line with the prefix for its operation.
+func printDiffLine(buff *bytes.Buffer, line diffLine) {
+	prefix := " "
+	switch line.op {
+	case diffInsert:
+		prefix = "+"
+	case diffDelete:
+		prefix = "-"
+	}
+	_, _ = buff.WriteString(prefix + strings.TrimSuffix(line.text, "\n") + "\n")
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diffing", func() {
+	DescribeTable("should diff the lines of the texts", func(from, to string, expected []diffLine) {
+		Expect(diffLines(from, to)).To(Equal(expected))
+	},
+		Entry("equal texts", "a\nb\n", "a\nb\n", []diffLine{{diffEqual, "a\n"}, {diffEqual, "b\n"}}),
+		Entry("a replaced line", "a\nb\nc\n", "a\nd\nc\n", []diffLine{{diffEqual, "a\n"}, {diffDelete, "b\n"}, {diffInsert, "d\n"}, {diffEqual, "c\n"}}),
+		Entry("an added line", "a\nc\n", "a\nb\nc\n", []diffLine{{diffEqual, "a\n"}, {diffInsert, "b\n"}, {diffEqual, "c\n"}}),
+		Entry("changes around a shared line", "a\nb\nc\n", "d\nb\ne\n", []diffLine{{diffDelete, "a\n"}, {diffInsert, "d\n"}, {diffEqual, "b\n"}, {diffDelete, "c\n"}, {diffInsert, "e\n"}}),
+	)
+
+	It("should diff long texts with changes far apart", func() {
+		var lines []string
+		for i := 1; i <= 20000; i++ {
+			lines = append(lines, fmt.Sprintf("line %d\n", i))
+		}
+		from := strings.Join(lines, "")
+		lines[4999] = "changed\n"
+		lines[14999] = "changed\n"
+		to := strings.Join(lines, "")
+
+		Expect(unifiedDiff("a", "b", from, to)).To(Equal(`--- a
++++ b
+@@ -4997,7 +4997,7 @@
+ line 4997
+ line 4998
+ line 4999
+-line 5000
++changed
+ line 5001
+ line 5002
+ line 5003
+@@ -14997,7 +14997,7 @@
+ line 14997
+ line 14998
+ line 14999
+-line 15000
++changed
+ line 15001
+ line 15002
+ line 15003
+`))
+	})
+})
@@ -0,0 +1,181 @@
+package generator
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	gotypes "go/types"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+	gengo "k8s.io/gengo/v2"
+	"k8s.io/gengo/v2/types"
+)
+
+// enumMarker marks a type whose exported typed constants are the full set of
+// its allowed values.
+const enumMarker = "reference-gen:enum"
+
+// enumValue is a typed constant declared for a type of the loaded packages.
+type enumValue struct {
+	// Name is the name of the constant.
+	Name string
+	// Value is the value of the constant, as written in the configuration.
+	Value string
+	// CommentLines are the doc comment of the constant.
+	CommentLines []string
+}
+
+// enumType is the set of values documented for a type.
+type enumType struct {
+	// Values are the values of the exported typed constants of the type.
+	Values []enumValue
+	// Closed is set when the type is marked as an enum, so that the values
+	// are known to be the full set of allowed values.
+	Closed bool
+}
+
+// packageEnums collects the exported typed constants of the packages, keyed by
+// their type. A type is an enum when it is marked with the enum marker, or when
+// it is a string or integer type, without an alias name or a common type, whose
+// constants are all declared together in one const block.
+// The values of each type are kept in the order that the constants are
+// declared in, from the positions of the loaded syntax.
+func packageEnums(pkgs []*types.Package, syntax map[string]*packages.Package, common commonTypes) map[*types.Type]enumType {
+	values := make(map[*types.Type][]enumValue)
+	positions := make(map[*types.Type]map[string]token.Pos)
+	blocks := make(map[*types.Type]map[token.Pos]bool)
+	for _, pkg := range pkgs {
+		var scope *gotypes.Scope
+		constBlocks := make(map[string]token.Pos)
+		if loaded, ok := syntax[pkg.Path]; ok && loaded.Types != nil {
+			scope = loaded.Types.Scope()
+			constBlocks = constDeclarations(loaded.Syntax)
+		}
+		for _, c := range pkg.Constants {
+			t := c.Underlying
+			if t == nil || c.ConstValue == nil || hideType(c) || t.Name.Package != pkg.Path {
+				// Untyped constants and constants of types from other
+				// packages do not describe the values of a local type.
+				continue
+			}
+			values[t] = append(values[t], enumValue{
+				Name:         c.Name.Name,
+				Value:        *c.ConstValue,
+				CommentLines: c.CommentLines,
+			})
+			if blocks[t] == nil {
+				blocks[t] = make(map[token.Pos]bool)
+			}
+			blocks[t][constBlocks[c.Name.Name]] = true
+			if scope != nil {
+				if obj := scope.Lookup(c.Name.Name); obj != nil {
+					if positions[t] == nil {
+						positions[t] = make(map[string]token.Pos)
+					}
+					positions[t][c.Name.Name] = obj.Pos()
+				}
+			}
+		}
+	}
+
+	out := make(map[*types.Type]enumType)
+	for t, vs := range values {
+		closed := hasEnumMarker(t)
+		if !closed && (len(blocks[t]) != 1 || !isEnumKind(t) || aliasNameOverride(t) != "") {
+			continue
+		}
+		if _, ok := common.lookup(t); ok && !closed {
+			continue
+		}
+		sort.Slice(vs, func(i, j int) bool {
+			a, b := positions[t][vs[i].Name], positions[t][vs[j].Name]
+			if a != b {
+				return a < b
+			}
+			return vs[i].Name < vs[j].Name
+		})
+		out[t] = enumType{Values: vs, Closed: closed}
+	}
+	return out
+}
+
+// constDeclarations maps the names of the constants declared in the files to
+// the position of the const declaration that declares them.
+func constDeclarations(files []*ast.File) map[string]token.Pos {
+	out := make(map[string]token.Pos)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					out[name.Name] = gen.Pos()
+				}
+			}
+		}
+	}
+	return out
+}
+
+// hasEnumMarker returns whether the type is marked as an enum.
+func hasEnumMarker(t *types.Type) bool {
+	tags := gengo.ExtractCommentTags("+", t.CommentLines)
+	_, ok := tags[enumMarker]
+	return ok
+}
+
+// isEnumKind returns whether the type is a string or an integer type.
+func isEnumKind(t *types.Type) bool {
+	if t.GoType == nil {
+		return false
+	}
+	basic, ok := t.GoType.Underlying().(*gotypes.Basic)
+	return ok && basic.Info()&(gotypes.IsString|gotypes.IsInteger) != 0
+}
+
+// enumValuesFunc constructs an enumValues function for the template
+// Composite types, such as slices or maps, take the values of the type within
+// them, when only one of the types within them is an enum.
+func enumValuesFunc(enums map[*types.Type]enumType) func(t *types.Type) []enumValue {
+	return func(t *types.Type) []enumValue {
+		var out []enumValue
+		for _, ref := range referencedTypes(t) {
+			if enum, ok := enums[ref]; ok {
+				if out != nil {
+					return nil
+				}
+				out = enum.Values
+			}
+		}
+		return out
+	}
+}
+
+// enumDisplayValue formats the value of the constant for the documentation.
+// Empty strings are quoted so that they remain visible.
+func enumDisplayValue(v enumValue) string {
+	if v.Value == "" {
+		return `""`
+	}
+	return v.Value
+}
+
+// schemaEnum converts the values of the constants to JSON values for the schema.
+func schemaEnum(values []enumValue, s *jsonSchema) []interface{} {
+	var out []interface{}
+	for _, v := range values {
+		if s.Type == "string" {
+			out = append(out, v.Value)
+			continue
+		}
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(v.Value), &parsed); err != nil {
+			parsed = v.Value
+		}
+		out = append(out, parsed)
+	}
+	return out
+}
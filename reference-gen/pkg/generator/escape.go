@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"strings"
+)
+
+// escaper escapes text for the markup of the output format, so that names and
+// descriptions are rendered literally and cannot break the tables they are
+// rendered in.
+type escaper struct {
+	// mdx also escapes the characters that MDX treats as JSX expressions.
+	mdx bool
+}
+
+func newEscaper(format string) escaper {
+	return escaper{mdx: format == OutputFormatMDX}
+}
+
+// text escapes inline text for use outside of a table.
+// Code spans are kept as they are, as their content is rendered literally.
+func (e escaper) text(s string) string {
+	return e.escape(s, false)
+}
+
+// cell escapes inline text for use in a table cell.
+// Pipes are escaped everywhere, including in code spans, as they would
+// otherwise end the cell.
+func (e escaper) cell(s string) string {
+	return e.escape(s, true)
+}
+
+// code escapes the text of a code block that is rendered as HTML in a table
+// cell.
+func (e escaper) code(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '|':
+			b.WriteString("&#124;")
+		case r == '{' && e.mdx:
+			b.WriteString("&#123;")
+		case r == '}' && e.mdx:
+			b.WriteString("&#125;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (e escaper) escape(s string, cell bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c == '`' {
+			n := backtickRun(s[i:])
+			if end := closingBackticks(s, i+n, n); end >= 0 {
+				code := s[i : end+n]
+				if cell {
+					code = strings.ReplaceAll(code, "|", `\|`)
+				}
+				b.WriteString(code)
+				i = end + n
+				continue
+			}
+			// Unmatched backticks would start a code span in a later cell.
+			b.WriteString(strings.Repeat("\\`", n))
+			i += n
+			continue
+		}
+
+		switch {
+		case c == '\\', c == '*', c == '_':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '<':
+			b.WriteString("&lt;")
+		case c == '>':
+			b.WriteString("&gt;")
+		case c == '|' && cell:
+			b.WriteString(`\|`)
+		case (c == '{' || c == '}') && e.mdx:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+		i++
+	}
+	return b.String()
+}
+
+// backtickRun returns the number of backticks at the start of the text.
+func backtickRun(s string) int {
+	n := 0
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	return n
+}
+
+// closingBackticks returns the index of the run of exactly n backticks that
+// closes a code span opened before the index given, or -1 if there is none.
+func closingBackticks(s string, from, n int) int {
+	for i := from; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := backtickRun(s[i:])
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
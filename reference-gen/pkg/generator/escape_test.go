@@ -0,0 +1,43 @@
+package generator
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Escaping", func() {
+	markdown := newEscaper(OutputFormatMarkdown)
+	mdx := newEscaper(OutputFormatMDX)
+
+	DescribeTable("should escape text in table cells", func(e escaper, in, expected string) {
+		Expect(e.cell(in)).To(Equal(expected))
+	},
+		Entry("pipes", markdown, "a | b", `a \| b`),
+		Entry("pipes in code spans", markdown, "use `a|b`", "use `a\\|b`"),
+		Entry("HTML-like text", markdown, "defaults to <nil>", "defaults to &lt;nil&gt;"),
+		Entry("emphasis characters", markdown, "a snake_case *name*", `a snake\_case \*name\*`),
+		Entry("unmatched backticks", markdown, "a ` b", "a \\` b"),
+		Entry("code spans with backticks", markdown, "`` a`b ``", "`` a`b ``"),
+		Entry("curly braces in Markdown", markdown, "{{ .Name }}", "{{ .Name }}"),
+		Entry("curly braces in MDX", mdx, "{{ .Name }}", `\{\{ .Name \}\}`),
+		Entry("curly braces in MDX code spans", mdx, "`{{ .Name }}`", "`{{ .Name }}`"),
+	)
+
+	It("should only escape pipes in table cells", func() {
+		Expect(markdown.text("a | <b>")).To(Equal("a | &lt;b&gt;"))
+	})
+
+	DescribeTable("should escape code blocks in table cells", func(e escaper, in, expected string) {
+		Expect(e.code(in)).To(Equal(expected))
+	},
+		Entry("in Markdown", markdown, "a | <b> & {c}", "a &#124; &lt;b&gt; &amp; {c}"),
+		Entry("in MDX", mdx, "a | <b> & {c}", "a &#124; &lt;b&gt; &amp; &#123;c&#125;"),
+	)
+
+	DescribeTable("should wrap text in a code span", func(in, expected string) {
+		Expect(backtick(in)).To(Equal(expected))
+	},
+		Entry("without backticks", "a|b", "`a|b`"),
+		Entry("with backticks", "a`b", "`` a`b ``"),
+	)
+})
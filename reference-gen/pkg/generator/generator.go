@@ -8,20 +8,25 @@ import (
 	"path/filepath"
 	"text/template"
 
-	"k8s.io/gengo/types"
+	"golang.org/x/tools/go/packages"
+	"k8s.io/gengo/v2/types"
 	"k8s.io/klog/v2"
 )
 
 const (
-	generatedTextWarning = "<!--- THIS FILE IS AUTOGENERATED!!! DO NOT EDIT!!! -->\n"
+	generatedTextNotice     = "THIS FILE IS AUTOGENERATED!!! DO NOT EDIT!!!"
+	generatedTextWarning    = "<!--- " + generatedTextNotice + " -->\n"
+	generatedTextWarningMDX = "{/* " + generatedTextNotice + " */}\n"
 )
 
 type Generator interface {
 	Run() error
 }
 
-func NewGenerator(packageName string, requestedTypesList []string, headerTextFile string, outputFileName string, templateDirectory string) (Generator, error) {
-	if packageName == "" {
+// NewGenerator constructs a generator for the given packages.
+// Packages may be import paths, directories or patterns such as ./pkg/apis/...
+func NewGenerator(packageNames []string, requestedTypesList []string, headerTextFile string, outputFileName string, templateDirectory string, opts ...Option) (Generator, error) {
+	if len(packageNames) == 0 {
 		return nil, errors.New("a package name must be specified")
 	}
 
@@ -34,13 +39,77 @@ func NewGenerator(packageName string, requestedTypesList []string, headerTextFil
 		return nil, fmt.Errorf("invalid template directory: %v", err)
 	}
 
-	return &generator{
-		packageName:       packageName,
+	g := &generator{
+		packageNames:      packageNames,
 		requestedTypes:    newStringSet(requestedTypesList),
 		headerText:        headerText,
 		outputFileName:    outputFileName,
 		templateDirectory: templateDirectory,
-	}, nil
+		outputFormat:      OutputFormatMarkdown,
+		externalLinks:     defaultExternalLinks(),
+		commonTypes:       defaultCommonTypes(),
+		defaults:          memberDefaults{tag: DefaultDefaultTag},
+		visibility:        visibility{minStability: StabilityAlpha, audiences: newStringSet(nil)},
+		tagPriority:       defaultTagPriority,
+	}
+	for _, opt := range opts {
+		if err := opt(g); err != nil {
+			return nil, fmt.Errorf("invalid option: %v", err)
+		}
+	}
+
+	if g.profile != nil {
+		if g.outputFormat == OutputFormatJSONSchema {
+			return nil, errors.New("a profile cannot be used with JSON Schema output")
+		}
+		g.outputFormat = g.profile.outputFormat
+		switch {
+		case !g.profile.frontMatterID:
+		case outputFileName != "":
+			g.frontMatter.setDefault("id", fileID(outputFileName))
+		case g.outputDirectory != "":
+			g.frontMatter.setDefault("id", indexFileBase)
+		}
+	}
+
+	if g.outputDirectory != "" {
+		if outputFileName != "" {
+			return nil, errors.New("an output file and an output directory cannot both be specified")
+		}
+		if g.outputFormat == OutputFormatJSONSchema {
+			return nil, errors.New("an output directory cannot be used with JSON Schema output")
+		}
+		if g.markers != nil {
+			return nil, errors.New("markers cannot be used with an output directory")
+		}
+	}
+
+	if g.keyIndexFileName != "" && g.outputFormat == OutputFormatJSONSchema {
+		return nil, errors.New("a key index cannot be used with JSON Schema output")
+	}
+
+	if g.keyIndexFileName != "" && outputFileName == "" && g.outputDirectory == "" {
+		return nil, errors.New("a key index needs an output file or directory for its links")
+	}
+
+	if g.checkOnly && outputFileName == "" && g.outputDirectory == "" {
+		return nil, errors.New("an output file must be specified to check against")
+	}
+
+	if g.outputFormat == OutputFormatJSONSchema && len(headerText) > 0 {
+		return nil, errors.New("a header file cannot be used with JSON Schema output")
+	}
+
+	if g.markers != nil {
+		if outputFileName == "" {
+			return nil, errors.New("an output file must be specified to inject into")
+		}
+		if g.outputFormat == OutputFormatJSONSchema {
+			return nil, errors.New("markers cannot be used with JSON Schema output")
+		}
+	}
+
+	return g, nil
 }
 
 // checkTemplateDir checks whether the template directory given exists and can be read
@@ -79,11 +148,35 @@ func loadHeaderText(fileName string) ([]byte, error) {
 }
 
 type generator struct {
-	packageName       string
+	packageNames      []string
 	requestedTypes    stringSet
 	headerText        []byte
 	outputFileName    string
 	templateDirectory string
+	outputFormat      string
+	checkOnly         bool
+	markers           *markers
+	buildTags         []string
+	externalLinks     externalLinks
+	commonTypes       commonTypes
+	defaults          memberDefaults
+	visibility        visibility
+	profile           *profile
+	frontMatter       frontMatter
+	outputDirectory   string
+	tableOfContents   bool
+	keyIndexFileName  string
+	omitEmptyOptional bool
+	tagPriority       []string
+	warnTagMismatch   bool
+	// partialTypes are the types with members left out of the documentation.
+	partialTypes typeSet
+
+	// packages are the loaded packages, sorted by path.
+	packages []*types.Package
+	// syntax holds the syntax and type information of the loaded packages,
+	// keyed by their path.
+	syntax map[string]*packages.Package
 }
 
 // Run runs the generation logic for the generator
@@ -97,75 +190,212 @@ func (g *generator) Run() error {
 		klog.Infof("Rendering reference for type: %s", typ.Name.Name)
 	}
 
-	if err := g.renderOutput(typesToRender); err != nil {
+	err = g.runReference(typesToRender)
+	if g.keyIndexFileName == "" {
+		return err
+	}
+	var staleErr *StaleOutputError
+	if err != nil && !errors.As(err, &staleErr) {
+		return err
+	}
+	// In check mode, the key index is checked even when the reference is
+	// stale, so that both diffs are reported.
+	keyErr := g.runKeyIndex(typesToRender)
+	if keyErr != nil && !errors.As(keyErr, &staleErr) {
+		return keyErr
+	}
+	return errors.Join(err, keyErr)
+}
+
+// runReference renders the reference and writes it to the output, or checks
+// the existing output against it.
+func (g *generator) runReference(typesToRender map[*types.Type][]*types.Type) error {
+	if g.outputDirectory != "" {
+		files, err := g.renderFiles(typesToRender)
+		if err != nil {
+			return fmt.Errorf("error rendering output: %v", err)
+		}
+		if g.checkOnly {
+			// Return the error unwrapped so that callers can inspect the diff.
+			return g.checkOutputDir(files)
+		}
+		if err := g.writeOutputDir(files); err != nil {
+			return fmt.Errorf("error writing output: %v", err)
+		}
+		return nil
+	}
+
+	content, err := g.renderOutput(typesToRender)
+	if err != nil {
 		return fmt.Errorf("error rendering output: %v", err)
 	}
 
+	if g.checkOnly {
+		// Return the error unwrapped so that callers can inspect the diff.
+		return g.checkOutput(content)
+	}
+
+	if err := g.writeOutput(content); err != nil {
+		return fmt.Errorf("error writing output: %v", err)
+	}
 	return nil
 }
 
-// loadTypes loads the package in the generator and returns a map
+// loadTypes loads the packages in the generator and returns a map
 // of types and the types that reference them.
 func (g *generator) loadTypesAndReferences() (map[*types.Type][]*types.Type, error) {
-	pkg, err := loadPackage(g.packageName)
+	pkgs, syntax, err := loadPackages(g.packageNames, g.buildTags)
 	if err != nil {
 		return nil, fmt.Errorf("could not load package: %v", err)
 	}
+	g.packages = pkgs
+	g.syntax = syntax
 
-	typeReferences := findTypeReferences(pkg.Types)
-	pkgTypeSet := newTypeSetFromStringMap(pkg.Types)
+	g.defaults.literals, err = readDefaultsFuncs(pkgs, syntax, g.defaults.funcs)
+	if err != nil {
+		return nil, fmt.Errorf("could not read defaults: %v", err)
+	}
+
+	allTypes := packageTypes(pkgs)
+	if err := checkStability(allTypes); err != nil {
+		return nil, err
+	}
+	allTypes, g.partialTypes = g.visibility.prune(allTypes, g.tagPriority)
+	if g.warnTagMismatch {
+		for _, mismatch := range tagMismatches(allTypes, g.tagPriority) {
+			klog.Warningf("%s", mismatch)
+		}
+	}
+
+	typeReferences := findTypeReferences(allTypes, g.tagPriority)
+	pkgTypeSet := newTypeSetFromStringMap(allTypes)
 
 	if !g.requestedTypes.isEmpty() {
 		typeReferences = filterToRequestedTypes(typeReferences, g.requestedTypes)
 	}
 
-	return filterToPackageTypes(typeReferences, pkgTypeSet), nil
+	typesToRender := filterToPackageTypes(typeReferences, pkgTypeSet)
+	if err := checkConstraints(createTypeList(typesToRender), g.tagPriority); err != nil {
+		return nil, err
+	}
+	return typesToRender, nil
+}
+
+// renderOutput renders the types into memory in the configured output format.
+func (g *generator) renderOutput(typesToRender map[*types.Type][]*types.Type) ([]byte, error) {
+	switch g.outputFormat {
+	case OutputFormatJSONSchema:
+		return g.renderSchema(typesToRender)
+	default:
+		return g.renderMarkdown(typesToRender)
+	}
 }
 
-func (g *generator) renderOutput(typesToRender map[*types.Type][]*types.Type) error {
+// renderMarkdown renders the types using the templates.
+func (g *generator) renderMarkdown(typesToRender map[*types.Type][]*types.Type) ([]byte, error) {
 	typeList := createTypeList(typesToRender)
+	knownTypes := newTypeIndex(typeList, g.commonTypes, g.tagPriority)
 
-	t, err := g.buildTemplate(typesToRender, typeList)
+	t, err := g.buildTemplate(typesToRender, typeList, knownTypes)
 	if err != nil {
-		return fmt.Errorf("error building template: %v", err)
+		return nil, fmt.Errorf("error building template: %v", err)
 	}
 
+	// Only part of the document is generated when injecting between markers.
+	warning := g.generatedWarning(g.markers != nil)
+
 	// Create a buffer and render everything into that before writing out
-	b := bytes.NewBuffer(append(g.headerText, []byte(generatedTextWarning)...))
+	b := &bytes.Buffer{}
+	if g.markers == nil {
+		// The front matter must be at the very start of the document.
+		if err := t.ExecuteTemplate(b, "front_matter", g.frontMatter); err != nil {
+			return nil, fmt.Errorf("error executing template: %v", err)
+		}
+	}
+	b.Write(g.headerText)
+	b.WriteString(warning)
 	if err := t.ExecuteTemplate(b, "package", map[string]interface{}{
-		"types": typeList,
+		"types":    typeList,
+		"packages": packageSections(g.packages, typeList),
 	}); err != nil {
-		return fmt.Errorf("error executing template: %v", err)
+		return nil, fmt.Errorf("error executing template: %v", err)
 	}
 
-	if err := g.writeOutput(b.Bytes()); err != nil {
-		return fmt.Errorf("error writing output: %v", err)
+	return b.Bytes(), nil
+}
+
+// generatedWarning returns the warning that the document, or a section of
+// the document, is generated, in the syntax of the output format.
+func (g *generator) generatedWarning(section bool) string {
+	switch {
+	case g.outputFormat == OutputFormatMDX && section:
+		return generatedSectionWarningMDX
+	case g.outputFormat == OutputFormatMDX:
+		return generatedTextWarningMDX
+	case section:
+		return generatedSectionWarning
+	default:
+		return generatedTextWarning
 	}
-	return nil
 }
 
-func (g *generator) buildTemplate(typesToRender map[*types.Type][]*types.Type, typeList []*types.Type) (*template.Template, error) {
-	knownTypes := newTypeSetFromList(typeList)
-	t := template.New("").Funcs(map[string]interface{}{
-		"aliasDisplayName": aliasDisplayNameFunc(knownTypes),
-		"backtick":         backtick,
-		"dereference":      tryDereference,
-		"fieldEmbedded":    fieldEmbedded,
-		"fieldName":        fieldName,
-		"hideMember":       hideMember,
-		"isOptionalMember": isOptionalMember,
-		"linkForType":      linkForTypeFunc(knownTypes),
-		"renderCommentsBR": renderCommentsBR,
-		"renderCommentsLF": renderCommentsLF,
-		"sortedTypes":      sortTypes,
-		"typeDisplayName":  typeDisplayNameFunc(knownTypes),
-		"typeReferences":   typeReferencesFunc(typesToRender, knownTypes),
-		"visibleMembers":   visibleMembers,
-		"visibleTypes":     visibleTypes,
+func (g *generator) buildTemplate(typesToRender map[*types.Type][]*types.Type, typeList []*types.Type, knownTypes *typeIndex) (*template.Template, error) {
+	links := g.externalLinks.withoutPackages(g.packages)
+	escaper := newEscaper(g.outputFormat)
+	comments := newCommentRenderer(knownTypes, links, escaper, g.packages)
+	members := newMemberIndex(typeList, typesToRender, knownTypes, g.tagPriority, g.defaults)
+	var t *template.Template
+	t = template.New("").Funcs(map[string]interface{}{
+		"aliasDisplayName":      aliasDisplayNameFunc(knownTypes),
+		"backtick":              backtick,
+		"commonTypeDescription": commonTypeDescriptionFunc(knownTypes),
+		"configKeys":            members.configKeys,
+		"constraints":           memberConstraints,
+		"defaultValue":          members.defaultValue,
+		"defaultsByPath":        members.defaultsByPath,
+		"deprecatedOptions":     deprecatedOptionsFunc(knownTypes, members),
+		"deprecation":           deprecationOf,
+		"dereference":           tryDereference,
+		"embeddedMembers":       members.embedded,
+		"enumDisplayValue":      enumDisplayValue,
+		"enumValues":            enumValuesFunc(packageEnums(g.packages, g.syntax, g.commonTypes)),
+		"escapeCell":            escaper.cell,
+		"escapeText":            escaper.text,
+		"fieldEmbedded":         fieldEmbeddedFunc(g.tagPriority),
+		"fieldName":             members.fieldName,
+		"headingAnchor":         headingAnchorFunc(knownTypes),
+		"hideMember":            hideMemberFunc(g.tagPriority),
+		"includeMember":         func(name string, m *types.Member) (string, error) { return includeMember(t, name, m) },
+		"isOptionalMember":      isOptionalMemberFunc(g.tagPriority, g.omitEmptyOptional),
+		"keyLink":               keyLinkFunc(members, g.keyIndexLinkBase()),
+		"linkForType":           linkForTypeFunc(knownTypes, links),
+		"linkedTypeDisplayName": linkedTypeDisplayNameFunc(knownTypes, links, escaper),
+		"memberAnchor":          members.anchor,
+		"memberLink":            members.link,
+		"memberPaths":           members.configPaths,
+		"recursiveMember":       members.recursive,
+		"tag":                   tagFunc(),
+		"renderCommentsBR":      comments.tableCell,
+		"replacementLink":       replacementLinkFunc(knownTypes, members, escaper),
+		"renderCommentsLF":      comments.markdown,
+		"showConstraintsColumn": showConstraintsColumnFunc(typeList, g.tagPriority),
+		"showDefaultColumn":     showDefaultColumnFunc(members, typeList),
+		"showTableOfContents":   func() bool { return g.tableOfContents },
+		"sortedTypes":           sortTypes,
+		"synopsis":              comments.synopsis,
+		"tableOfContents":       tableOfContentsFunc(typesToRender, knownTypes),
+		"trimPrefix":            trimPrefix,
+		"typeDisplayName":       typeDisplayNameFunc(knownTypes),
+		"typeName":              knownTypes.name,
+		"typeReferences":        typeReferencesFunc(typesToRender, knownTypes),
+		"unstable":              unstable,
+		"visibleMembers":        visibleMembersFunc(g.tagPriority),
+		"visibleTypes":          visibleTypes,
+		"yamlValue":             yamlValue,
 	})
 
 	var err error
-	t, err = loadTemplatesInto(t, g.templateDirectory)
+	t, err = loadTemplatesInto(t, g.profile, g.templateDirectory)
 	if err != nil {
 		return nil, fmt.Errorf("error loading templates: %v", err)
 	}
@@ -183,6 +413,17 @@ func (g *generator) writeOutput(content []byte) error {
 		return nil
 	}
 
+	if g.markers != nil {
+		existing, err := os.ReadFile(g.outputFileName)
+		if err != nil {
+			return fmt.Errorf("could not read file %q: %v", g.outputFileName, err)
+		}
+		content, err = injectBetweenMarkers(existing, content, *g.markers)
+		if err != nil {
+			return fmt.Errorf("could not inject output into %q: %v", g.outputFileName, err)
+		}
+	}
+
 	if err := os.WriteFile(g.outputFileName, content, 0600); err != nil {
 		return fmt.Errorf("could not write file %q: %v", g.outputFileName, err)
 	}
@@ -190,3 +431,51 @@ func (g *generator) writeOutput(content []byte) error {
 
 	return nil
 }
+
+// checkOutput compares the rendered content with the existing output file.
+// A StaleOutputError is returned when the two differ.
+func (g *generator) checkOutput(content []byte) error {
+	if g.markers != nil {
+		existing, err := os.ReadFile(g.outputFileName)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not read file %q: %v", g.outputFileName, err)
+		}
+		content, err = injectBetweenMarkers(existing, content, *g.markers)
+		if err != nil {
+			return fmt.Errorf("could not inject output into %q: %v", g.outputFileName, err)
+		}
+	}
+	return checkFile(g.outputFileName, content)
+}
+
+// checkFile compares the rendered content with the existing file.
+// A StaleOutputError is returned when the two differ.
+func checkFile(fileName string, content []byte) error {
+	existing, err := os.ReadFile(fileName)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read file %q: %v", fileName, err)
+	}
+
+	if bytes.Equal(existing, content) {
+		klog.Infof("Rendered output matches %q", fileName)
+		return nil
+	}
+
+	return &StaleOutputError{
+		FileName: fileName,
+		Diff:     unifiedDiff(fileName, fileName+" (generated)", string(existing), string(content)),
+	}
+}
+
+// StaleOutputError is returned in check mode when the existing output file
+// does not match the generated output.
+type StaleOutputError struct {
+	// FileName is the name of the output file, or directory, that is out of date.
+	FileName string
+	// Diff is a unified diff from the existing file to the generated output.
+	Diff string
+}
+
+func (e *StaleOutputError) Error() string {
+	return fmt.Sprintf("output file %q is out of date, regenerate it to apply the changes", e.FileName)
+}
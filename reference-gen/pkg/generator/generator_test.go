@@ -3,8 +3,10 @@ package generator
 import (
 	"bytes"
 	"embed"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/go-git/go-git/v5/utils/diff"
@@ -17,27 +19,42 @@ const (
 	testDataPackage = "github.com/oauth2-proxy/tools/reference-gen/pkg/generator/testdata/"
 )
 
-//go:embed testdata/*.md
+//go:embed testdata/*.md testdata/*.mdx testdata/*.json testdata/split/*.md
 var testOutputs embed.FS
 
 var _ = Describe("Generator", func() {
 	type generatorTableInput struct {
 		requestedTypes         []string
 		headerFileName         string
+		templateDirectory      string
 		expectedOutputFileName string
+		options                []Option
+		// packages are the test data packages to generate from, defaults to json & yaml.
+		// Relative patterns are loaded as they are, from the package directory.
+		packages []string
+		// packageOutputFileNames override the expected output of the packages
+		// whose output differs from that of the other packages.
+		packageOutputFileNames map[string]string
 	}
 
-	DescribeTable("should generate the expected output for json & yaml tags", func(in generatorTableInput) {
-		for _, pkg := range []string{"json", "yaml"} {
+	DescribeTable("should generate the expected output", func(in generatorTableInput) {
+		if in.packages == nil {
+			in.packages = []string{"json", "yaml"}
+		}
+		for _, pkg := range in.packages {
 			By(pkg + ": Creating an output file")
-			outputFile, err := os.CreateTemp("", pkg+"-oauth2-proxy-reference-generator-suite-")
+			outputFile, err := os.CreateTemp("", path.Base(pkg)+"-oauth2-proxy-reference-generator-suite-")
 			Expect(err).ToNot(HaveOccurred())
 
 			outputFileName := outputFile.Name()
 			Expect(outputFile.Close()).To(Succeed())
 
 			By(pkg + ": Constructing the generator")
-			gen, err := NewGenerator(testDataPackage+pkg, in.requestedTypes, in.headerFileName, outputFileName, "")
+			pattern := testDataPackage + pkg
+			if strings.HasPrefix(pkg, "./") {
+				pattern = pkg
+			}
+			gen, err := NewGenerator([]string{pattern}, in.requestedTypes, in.headerFileName, outputFileName, in.templateDirectory, in.options...)
 			Expect(err).ToNot(HaveOccurred())
 
 			By(pkg + ": Running the generator")
@@ -48,7 +65,11 @@ var _ = Describe("Generator", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			By(pkg + ": Loading the expected output")
-			expectedOutput, err := testOutputs.ReadFile(in.expectedOutputFileName)
+			expectedOutputFileName := in.expectedOutputFileName
+			if name, ok := in.packageOutputFileNames[pkg]; ok {
+				expectedOutputFileName = name
+			}
+			expectedOutput, err := testOutputs.ReadFile(expectedOutputFileName)
 			Expect(err).ToNot(HaveOccurred())
 
 			By(pkg + ": Comparing the outputs")
@@ -63,22 +84,597 @@ var _ = Describe("Generator", func() {
 		Entry("With the full test structure, pulls in references for all substructs", generatorTableInput{
 			requestedTypes:         []string{"MyTestStruct"},
 			expectedOutputFileName: "testdata/fullMyTestStruct.md",
+			packageOutputFileNames: map[string]string{"yaml": "testdata/fullMyTestStructYAML.md"},
 		}),
 		Entry("With only a sub test structure", generatorTableInput{
 			requestedTypes:         []string{"SomeSubStruct"},
 			expectedOutputFileName: "testdata/someSubStructOnly.md",
+			packageOutputFileNames: map[string]string{"yaml": "testdata/someSubStructOnlyYAML.md"},
 		}),
 		Entry("With a header file specified, should prefix the generated content", generatorTableInput{
 			requestedTypes:         []string{"SomeSubStruct"},
 			expectedOutputFileName: "testdata/someSubStructWithHeader.md",
+			packageOutputFileNames: map[string]string{"yaml": "testdata/someSubStructWithHeaderYAML.md"},
 			headerFileName:         "testdata/header.md",
 		}),
 		Entry("With two unrelated structs", generatorTableInput{
 			requestedTypes:         []string{"SomeSubStruct", "AnEmbeddedStruct"},
 			expectedOutputFileName: "testdata/unrelatedStructs.md",
+			packageOutputFileNames: map[string]string{"yaml": "testdata/unrelatedStructsYAML.md"},
 			headerFileName:         "testdata/header.md",
 		}),
+		Entry("With JSON Schema output, renders the full test structure as a schema", generatorTableInput{
+			requestedTypes:         []string{"MyTestStruct"},
+			expectedOutputFileName: "testdata/fullMyTestStruct.schema.json",
+			packageOutputFileNames: map[string]string{"yaml": "testdata/fullMyTestStructYAML.schema.json"},
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema)},
+		}),
+		Entry("With JSON Schema output and two root types, renders a root for each", generatorTableInput{
+			requestedTypes:         []string{"SomeSubStruct", "AnEmbeddedStruct"},
+			expectedOutputFileName: "testdata/unrelatedStructs.schema.json",
+			packageOutputFileNames: map[string]string{"yaml": "testdata/unrelatedStructsYAML.schema.json"},
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema)},
+		}),
+		Entry("Without build tags, excludes files that require a build tag", generatorTableInput{
+			requestedTypes:         []string{"TaggedStruct"},
+			expectedOutputFileName: "testdata/taggedStruct.md",
+			packages:               []string{"buildtags"},
+		}),
+		Entry("With build tags, includes files that require the build tag", generatorTableInput{
+			requestedTypes:         []string{"TaggedStruct"},
+			expectedOutputFileName: "testdata/taggedStructExtra.md",
+			options:                []Option{WithBuildTags([]string{"extra"})},
+			packages:               []string{"buildtags"},
+		}),
+		Entry("With multiple packages, renders a section per package with unique anchors", generatorTableInput{
+			requestedTypes:         []string{"server.Options"},
+			expectedOutputFileName: "testdata/multiPackage.md",
+			packages:               []string{"./testdata/multi/..."},
+		}),
+		Entry("With packages sharing a base name and JSON Schema output, escapes the references to their types", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			expectedOutputFileName: "testdata/sameName.schema.json",
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema)},
+			packages:               []string{"./testdata/samename/..."},
+		}),
+		Entry("With external link rules, links types outside of the loaded packages", generatorTableInput{
+			expectedOutputFileName: "testdata/externalLinks.md",
+			options:                []Option{WithExternalLinks([]string{"github.com/oauth2-proxy/tools/reference-gen/pkg/generator/testdata/multi/*=https://example.com/{{package}}#{{name}}"})},
+			packages:               []string{"./testdata/multi/server"},
+		}),
+		Entry("With an external links file, links types outside of the loaded packages", generatorTableInput{
+			expectedOutputFileName: "testdata/externalLinks.md",
+			options:                []Option{WithExternalLinksFile("testdata/externalLinks.yaml")},
+			packages:               []string{"./testdata/multi/server"},
+		}),
+		Entry("With a common type override, displays the type by its common name", generatorTableInput{
+			requestedTypes:         []string{"Upstream"},
+			expectedOutputFileName: "testdata/commonTypesFlag.md",
+			options:                []Option{WithCommonTypes([]string{"net/url.URL=string (URL)"})},
+			packages:               []string{"commontypes"},
+		}),
+		Entry("With a common types file, displays the types with their name, description and link", generatorTableInput{
+			requestedTypes:         []string{"Upstream"},
+			expectedOutputFileName: "testdata/commonTypes.md",
+			options:                []Option{WithCommonTypesFile("testdata/commonTypes.yaml")},
+			packages:               []string{"commontypes"},
+		}),
+		Entry("With default values, adds a default column from markers and struct tags", generatorTableInput{
+			requestedTypes:         []string{"Server"},
+			expectedOutputFileName: "testdata/defaults.md",
+			packages:               []string{"defaults"},
+		}),
+		Entry("Without a default tag, only reads default values from markers", generatorTableInput{
+			requestedTypes:         []string{"Server"},
+			expectedOutputFileName: "testdata/defaultsMarkersOnly.md",
+			options:                []Option{WithDefaultTag("")},
+			packages:               []string{"defaults"},
+		}),
+		Entry("With a defaults function, reads default values from the returned literal", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			expectedOutputFileName: "testdata/defaultsFunc.md",
+			options:                []Option{WithDefaultsFuncs([]string{"NewOptions"})},
+			packages:               []string{"defaultsfunc"},
+		}),
+		Entry("With a qualified defaults function, reads default values from the returned literal", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			expectedOutputFileName: "testdata/defaultsFunc.md",
+			options:                []Option{WithDefaultsFuncs([]string{testDataPackage + "defaultsfunc.NewOptions"})},
+			packages:               []string{"defaultsfunc"},
+		}),
+		Entry("With typed constants, lists the allowed values of the types", generatorTableInput{
+			requestedTypes:         []string{"Provider"},
+			expectedOutputFileName: "testdata/enums.md",
+			packages:               []string{"enums"},
+		}),
+		Entry("With typed constants and JSON Schema output, adds an enum to the marked types", generatorTableInput{
+			requestedTypes:         []string{"Provider"},
+			expectedOutputFileName: "testdata/enums.schema.json",
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema)},
+			packages:               []string{"enums"},
+		}),
+		Entry("With validation markers, adds a constraints column", generatorTableInput{
+			requestedTypes:         []string{"Upstream"},
+			expectedOutputFileName: "testdata/validation.md",
+			packages:               []string{"validation"},
+		}),
+		Entry("With validation markers and JSON Schema output, adds the constraints to the schema", generatorTableInput{
+			requestedTypes:         []string{"Upstream"},
+			expectedOutputFileName: "testdata/validation.schema.json",
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema)},
+			packages:               []string{"validation"},
+		}),
+		Entry("With deprecated types and fields, marks them and lists them in a summary", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			expectedOutputFileName: "testdata/deprecation.md",
+			packages:               []string{"deprecation"},
+		}),
+		Entry("With deprecated types and fields and JSON Schema output, marks them as deprecated", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			expectedOutputFileName: "testdata/deprecation.schema.json",
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema)},
+			packages:               []string{"deprecation"},
+		}),
+		Entry("With stability markers, badges alpha and beta types and fields", generatorTableInput{
+			expectedOutputFileName: "testdata/stability.md",
+			packages:               []string{"stability"},
+		}),
+		Entry("With a minimum stability of beta, removes alpha fields, fields of slices of alpha types and the types only reachable through them", generatorTableInput{
+			expectedOutputFileName: "testdata/stabilityBeta.md",
+			options:                []Option{WithMinStability(StabilityBeta)},
+			packages:               []string{"stability"},
+		}),
+		Entry("With a minimum stability of stable, removes alpha and beta types and fields", generatorTableInput{
+			expectedOutputFileName: "testdata/stabilityStable.md",
+			options:                []Option{WithMinStability(StabilityStable)},
+			packages:               []string{"stability"},
+		}),
+		Entry("With hidden and audience markers, removes hidden types and fields and those for other audiences", generatorTableInput{
+			expectedOutputFileName: "testdata/audience.md",
+			packages:               []string{"audience"},
+		}),
+		Entry("With hidden fields and JSON Schema output, allows the properties of the hidden fields", generatorTableInput{
+			expectedOutputFileName: "testdata/audience.schema.json",
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema)},
+			packages:               []string{"audience"},
+		}),
+		Entry("With an audience, includes the types and fields for the audience", generatorTableInput{
+			expectedOutputFileName: "testdata/audienceInternal.md",
+			options:                []Option{WithAudiences([]string{"internal"})},
+			packages:               []string{"audience"},
+		}),
+		Entry("With doc comments, renders lists, code blocks, headings and doc links as Markdown", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			expectedOutputFileName: "testdata/docComments.md",
+			packages:               []string{"doccomments"},
+		}),
+		Entry("With special characters in names and descriptions, escapes them for Markdown", generatorTableInput{
+			requestedTypes:         []string{"Upstream"},
+			expectedOutputFileName: "testdata/escaping.md",
+			packages:               []string{"escaping"},
+		}),
+		Entry("With special characters in names and descriptions and MDX output, escapes them for MDX", generatorTableInput{
+			requestedTypes:         []string{"Upstream"},
+			expectedOutputFileName: "testdata/escaping.mdx",
+			options:                []Option{WithOutputFormat(OutputFormatMDX)},
+			packages:               []string{"escaping"},
+		}),
+		Entry("With the docusaurus profile, renders MDX with front matter and admonitions", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			expectedOutputFileName: "testdata/docusaurus.mdx",
+			options: []Option{
+				WithProfile(ProfileDocusaurus),
+				WithFrontMatter([]string{"id=configuration", "title=Configuration: Reference", "sidebar_position=3"}),
+			},
+			packages: []string{"docusaurus"},
+		}),
+		Entry("With a template directory, overrides only the templates it defines", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			templateDirectory:      "testdata/templates",
+			expectedOutputFileName: "testdata/stabilityTemplates.md",
+			packages:               []string{"stability"},
+		}),
+		Entry("With a table of contents, groups the types by the root types they are reachable from", generatorTableInput{
+			expectedOutputFileName: "testdata/toc.md",
+			options:                []Option{WithTableOfContents(true)},
+			packages:               []string{"toc"},
+		}),
+		Entry("With a template directory, can include the table of contents", generatorTableInput{
+			templateDirectory:      "testdata/templates/toc",
+			expectedOutputFileName: "testdata/tocTemplate.md",
+			packages:               []string{"toc"},
+		}),
+		Entry("With nested types, adds an anchor and the config paths to each field", generatorTableInput{
+			requestedTypes:         []string{"AlphaOptions"},
+			expectedOutputFileName: "testdata/paths.md",
+			packages:               []string{"paths"},
+		}),
+		Entry("With recursive types, marks the fields that refer back to their own type", generatorTableInput{
+			requestedTypes:         []string{"Config"},
+			expectedOutputFileName: "testdata/recursive.md",
+			packages:               []string{"recursive"},
+		}),
+		Entry("With recursive types and JSON Schema output, references the definitions of the types", generatorTableInput{
+			requestedTypes:         []string{"Config"},
+			expectedOutputFileName: "testdata/recursive.schema.json",
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema)},
+			packages:               []string{"recursive"},
+		}),
+		Entry("With composite types, renders the type expressions and links each type within them", generatorTableInput{
+			requestedTypes:         []string{"Config"},
+			expectedOutputFileName: "testdata/expressions.md",
+			packages:               []string{"expressions"},
+		}),
+		Entry("With composite types and JSON Schema output, builds the schema of the type expressions", generatorTableInput{
+			requestedTypes:         []string{"Config"},
+			expectedOutputFileName: "testdata/expressions.schema.json",
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema)},
+			packages:               []string{"expressions"},
+		}),
+		Entry("With json tags, documents the fields as encoding/json marshals them", generatorTableInput{
+			requestedTypes:         []string{"FieldSemantics"},
+			expectedOutputFileName: "testdata/fieldSemanticsJSON.md",
+			packages:               []string{"json"},
+		}),
+		Entry("With yaml tags, documents the fields as yaml marshals them", generatorTableInput{
+			requestedTypes:         []string{"FieldSemantics"},
+			expectedOutputFileName: "testdata/fieldSemanticsYAML.md",
+			packages:               []string{"yaml"},
+		}),
+		Entry("With omitempty implying optional, marks the fields with omitempty as optional", generatorTableInput{
+			requestedTypes:         []string{"FieldSemantics"},
+			expectedOutputFileName: "testdata/fieldSemanticsOmitEmpty.md",
+			options:                []Option{WithOmitEmptyOptional(true)},
+			packages:               []string{"json"},
+		}),
+		Entry("With omitempty implying optional and JSON Schema output, only requires the fields without omitempty", generatorTableInput{
+			requestedTypes:         []string{"FieldSemantics"},
+			expectedOutputFileName: "testdata/fieldSemantics.schema.json",
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema), WithOmitEmptyOptional(true)},
+			packages:               []string{"json"},
+		}),
+		Entry("With a tag priority, documents the fields as marshalled with the first tag they have", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			expectedOutputFileName: "testdata/tagPriority.md",
+			options:                []Option{WithTagPriority([]string{"mapstructure", "toml", "json"})},
+			packages:               []string{"tags"},
+		}),
+		Entry("Without a tag priority, documents the fields as marshalled with their json or yaml tag", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			expectedOutputFileName: "testdata/tagDefault.md",
+			packages:               []string{"tags"},
+		}),
+		Entry("With a template directory, can read any struct tag of the fields", generatorTableInput{
+			requestedTypes:         []string{"Options"},
+			templateDirectory:      "testdata/templates/tags",
+			expectedOutputFileName: "testdata/tagTemplate.md",
+			options:                []Option{WithTagPriority([]string{"mapstructure", "toml", "json"})},
+			packages:               []string{"tags"},
+		}),
+		Entry("With default values and JSON Schema output, adds the defaults to the schema", generatorTableInput{
+			requestedTypes:         []string{"Server"},
+			expectedOutputFileName: "testdata/defaults.schema.json",
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema)},
+			packages:               []string{"defaults"},
+		}),
+		Entry("With a common types file and JSON Schema output, uses the schema of the common name", generatorTableInput{
+			requestedTypes:         []string{"Upstream"},
+			expectedOutputFileName: "testdata/commonTypes.schema.json",
+			options:                []Option{WithOutputFormat(OutputFormatJSONSchema), WithCommonTypesFile("testdata/commonTypes.yaml")},
+			packages:               []string{"commontypes"},
+		}),
 	)
+
+	It("should name the package and file when the package cannot be loaded", func() {
+		gen, err := NewGenerator([]string{testDataPackage + "broken"}, nil, "", "", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gen.Run()).To(MatchError(MatchRegexp(`^unable to load types: could not load package: package "` + testDataPackage + `broken": .*/testdata/broken/types.go:6:10: undefined: UndefinedType$`)))
+	})
+
+	Context("in check mode", func() {
+		var outputFileName string
+
+		BeforeEach(func() {
+			outputFile, err := os.CreateTemp("", "check-oauth2-proxy-reference-generator-suite-")
+			Expect(err).ToNot(HaveOccurred())
+			outputFileName = outputFile.Name()
+			Expect(outputFile.Close()).To(Succeed())
+
+			DeferCleanup(os.Remove, outputFileName)
+		})
+
+		It("should succeed when the output file is up to date", func() {
+			expectedOutput, err := testOutputs.ReadFile("testdata/someSubStructOnly.md")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(outputFileName, expectedOutput, 0600)).To(Succeed())
+
+			gen, err := NewGenerator([]string{testDataPackage + "json"}, []string{"SomeSubStruct"}, "", outputFileName, "", WithCheckOnly(true))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gen.Run()).To(Succeed())
+		})
+
+		It("should return a diff and leave the file untouched when the output file is stale", func() {
+			expectedOutput, err := testOutputs.ReadFile("testdata/someSubStructOnly.md")
+			Expect(err).ToNot(HaveOccurred())
+			staleOutput := []byte(strings.Replace(string(expectedOutput), "NonTaggedField doesn't", "NonTaggedField does not", 1))
+			Expect(os.WriteFile(outputFileName, staleOutput, 0600)).To(Succeed())
+
+			gen, err := NewGenerator([]string{testDataPackage + "json"}, []string{"SomeSubStruct"}, "", outputFileName, "", WithCheckOnly(true))
+			Expect(err).ToNot(HaveOccurred())
+
+			err = gen.Run()
+			var staleErr *StaleOutputError
+			Expect(errors.As(err, &staleErr)).To(BeTrue())
+			Expect(staleErr.FileName).To(Equal(outputFileName))
+			Expect(staleErr.Diff).To(Equal(fmt.Sprintf(`--- %[1]s
++++ %[1]s (generated)
+@@ -6,5 +6,5 @@
+ 
+ | Field | Type | Description |
+ | ----- | ---- | ----------- |
+-| <a id="somesubstruct-nontaggedfield"></a>`+"`NonTaggedField`"+` | _bool_ | NonTaggedField does not have a tag, so it is named as the tags of the other fields of the struct would name it. |
++| <a id="somesubstruct-nontaggedfield"></a>`+"`NonTaggedField`"+` | _bool_ | NonTaggedField doesn't have a tag, so it is named as the tags of the other fields of the struct would name it. |
+ | <a id="somesubstruct-taggedfield"></a>`+"`taggedField`"+` | _bool_ | TaggedField has a tag, which sets the format of the struct. |
+`, outputFileName)))
+
+			output, err := os.ReadFile(outputFileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal(staleOutput))
+		})
+
+		It("should require an output file", func() {
+			_, err := NewGenerator([]string{testDataPackage + "json"}, nil, "", "", "", WithCheckOnly(true))
+			Expect(err).To(MatchError("an output file must be specified to check against"))
+		})
+	})
+
+	Context("when injecting between markers", func() {
+		const document = `# Hand written title
+
+Some hand written prose.
+
+<!-- reference-gen:begin -->
+This content will be replaced.
+<!-- reference-gen:end -->
+
+More hand written prose.
+`
+		var outputFileName string
+
+		BeforeEach(func() {
+			outputFile, err := os.CreateTemp("", "inject-oauth2-proxy-reference-generator-suite-")
+			Expect(err).ToNot(HaveOccurred())
+			outputFileName = outputFile.Name()
+			Expect(outputFile.Close()).To(Succeed())
+
+			DeferCleanup(os.Remove, outputFileName)
+		})
+
+		It("should replace only the content between the markers", func() {
+			Expect(os.WriteFile(outputFileName, []byte(document), 0600)).To(Succeed())
+
+			gen, err := NewGenerator([]string{testDataPackage + "json"}, []string{"SomeSubStruct"}, "", outputFileName, "", WithInjectMarkers("", ""))
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Running the generator twice, the output should not change")
+			for i := 0; i < 2; i++ {
+				Expect(gen.Run()).To(Succeed())
+
+				output, err := os.ReadFile(outputFileName)
+				Expect(err).ToNot(HaveOccurred())
+				expectedOutput, err := testOutputs.ReadFile("testdata/someSubStructInjected.md")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(output)).To(Equal(string(expectedOutput)))
+			}
+
+			By("Checking the injected output")
+			gen, err = NewGenerator([]string{testDataPackage + "json"}, []string{"SomeSubStruct"}, "", outputFileName, "", WithInjectMarkers("", ""), WithCheckOnly(true))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gen.Run()).To(Succeed())
+		})
+
+		DescribeTable("should fail when the markers are invalid", func(doc string, expectedErr string) {
+			Expect(os.WriteFile(outputFileName, []byte(doc), 0600)).To(Succeed())
+
+			gen, err := NewGenerator([]string{testDataPackage + "json"}, []string{"SomeSubStruct"}, "", outputFileName, "", WithInjectMarkers("", ""))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gen.Run()).To(MatchError(fmt.Sprintf("error writing output: could not inject output into %q: %s", outputFileName, expectedErr)))
+
+			output, err := os.ReadFile(outputFileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(output)).To(Equal(doc))
+		},
+			Entry("with no markers", "# Title\n",
+				`marker "<!-- reference-gen:begin -->" not found`),
+			Entry("with a missing end marker", "<!-- reference-gen:begin -->\n",
+				`marker "<!-- reference-gen:end -->" not found`),
+			Entry("with a duplicated begin marker", "<!-- reference-gen:begin -->\n<!-- reference-gen:begin -->\n<!-- reference-gen:end -->\n",
+				`marker "<!-- reference-gen:begin -->" found 2 times, expected it once`),
+			Entry("with the markers in the wrong order", "<!-- reference-gen:end -->\n<!-- reference-gen:begin -->\n",
+				`end marker "<!-- reference-gen:end -->" appears before begin marker "<!-- reference-gen:begin -->"`),
+		)
+	})
+
+	Context("with an output directory", func() {
+		var outputDir string
+
+		BeforeEach(func() {
+			var err error
+			outputDir, err = os.MkdirTemp("", "split-oauth2-proxy-reference-generator-suite-")
+			Expect(err).ToNot(HaveOccurred())
+
+			DeferCleanup(os.RemoveAll, outputDir)
+		})
+
+		It("should write a file for each type, an index and a manifest", func() {
+			gen, err := NewGenerator([]string{testDataPackage + "docusaurus"}, nil, "", "", "", WithOutputDirectory(outputDir))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gen.Run()).To(Succeed())
+
+			expectedFiles, err := testOutputs.ReadDir("testdata/split")
+			Expect(err).ToNot(HaveOccurred())
+			for _, f := range expectedFiles {
+				output, err := os.ReadFile(path.Join(outputDir, f.Name()))
+				Expect(err).ToNot(HaveOccurred())
+				expectedOutput, err := testOutputs.ReadFile(path.Join("testdata/split", f.Name()))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(output)).To(Equal(string(expectedOutput)), f.Name())
+			}
+
+			manifest, err := os.ReadFile(path.Join(outputDir, manifestFileName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(manifest)).To(Equal("index.md\nlegacysession.md\noptions.md\nsession.md\ntracing.md\n"))
+		})
+
+		It("should remove the files of types that are no longer documented", func() {
+			gen, err := NewGenerator([]string{testDataPackage + "docusaurus"}, nil, "", "", "", WithOutputDirectory(outputDir))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gen.Run()).To(Succeed())
+			Expect(os.WriteFile(path.Join(outputDir, "notes.md"), []byte("Hand written notes.\n"), 0600)).To(Succeed())
+
+			gen, err = NewGenerator([]string{testDataPackage + "docusaurus"}, []string{"Session"}, "", "", "", WithOutputDirectory(outputDir))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gen.Run()).To(Succeed())
+
+			entries, err := os.ReadDir(outputDir)
+			Expect(err).ToNot(HaveOccurred())
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			Expect(names).To(ConsistOf(manifestFileName, "index.md", "notes.md", "session.md"))
+		})
+
+		It("should return a diff when the files are stale in check mode", func() {
+			gen, err := NewGenerator([]string{testDataPackage + "docusaurus"}, nil, "", "", "", WithOutputDirectory(outputDir))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gen.Run()).To(Succeed())
+
+			By("Checking the files that were just written")
+			gen, err = NewGenerator([]string{testDataPackage + "docusaurus"}, nil, "", "", "", WithOutputDirectory(outputDir), WithCheckOnly(true))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gen.Run()).To(Succeed())
+
+			By("Checking after a type is no longer documented")
+			gen, err = NewGenerator([]string{testDataPackage + "docusaurus"}, []string{"Session"}, "", "", "", WithOutputDirectory(outputDir), WithCheckOnly(true))
+			Expect(err).ToNot(HaveOccurred())
+			err = gen.Run()
+			var staleErr *StaleOutputError
+			Expect(errors.As(err, &staleErr)).To(BeTrue())
+			Expect(staleErr.FileName).To(Equal(outputDir))
+			Expect(staleErr.Diff).To(ContainSubstring("+++ " + path.Join(outputDir, manifestFileName) + " (generated)"))
+			Expect(staleErr.Diff).To(ContainSubstring("+++ " + path.Join(outputDir, "tracing.md") + " (removed)"))
+			Expect(path.Join(outputDir, "tracing.md")).To(BeAnExistingFile())
+		})
+
+		It("should not allow an output file as well", func() {
+			_, err := NewGenerator([]string{testDataPackage + "json"}, nil, "", "out.md", "", WithOutputDirectory(outputDir))
+			Expect(err).To(MatchError("an output file and an output directory cannot both be specified"))
+		})
+	})
+
+	Context("with a key index", func() {
+		var outputDir string
+
+		BeforeEach(func() {
+			var err error
+			outputDir, err = os.MkdirTemp("", "keys-oauth2-proxy-reference-generator-suite-")
+			Expect(err).ToNot(HaveOccurred())
+
+			DeferCleanup(os.RemoveAll, outputDir)
+		})
+
+		It("should write every configuration key, linked to the reference", func() {
+			gen, err := NewGenerator([]string{testDataPackage + "paths"}, nil, "", path.Join(outputDir, "reference.md"), "", WithKeyIndexFile(path.Join(outputDir, "keys.md")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gen.Run()).To(Succeed())
+
+			output, err := os.ReadFile(path.Join(outputDir, "keys.md"))
+			Expect(err).ToNot(HaveOccurred())
+			expectedOutput, err := testOutputs.ReadFile("testdata/keys.md")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(output)).To(Equal(string(expectedOutput)))
+		})
+
+		It("should return a diff when the key index is stale in check mode", func() {
+			keyIndexFile := path.Join(outputDir, "keys.md")
+			gen, err := NewGenerator([]string{testDataPackage + "paths"}, nil, "", path.Join(outputDir, "reference.md"), "", WithKeyIndexFile(keyIndexFile))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gen.Run()).To(Succeed())
+			Expect(os.WriteFile(keyIndexFile, []byte("Outdated keys.\n"), 0600)).To(Succeed())
+
+			gen, err = NewGenerator([]string{testDataPackage + "paths"}, nil, "", path.Join(outputDir, "reference.md"), "", WithKeyIndexFile(keyIndexFile), WithCheckOnly(true))
+			Expect(err).ToNot(HaveOccurred())
+			err = gen.Run()
+			var staleErr *StaleOutputError
+			Expect(errors.As(err, &staleErr)).To(BeTrue())
+			Expect(staleErr.FileName).To(Equal(keyIndexFile))
+			Expect(staleErr.Diff).To(ContainSubstring("-Outdated keys."))
+		})
+
+		It("should not allow JSON Schema output", func() {
+			_, err := NewGenerator([]string{testDataPackage + "json"}, nil, "", "", "", WithKeyIndexFile("keys.md"), WithOutputFormat(OutputFormatJSONSchema))
+			Expect(err).To(MatchError("a key index cannot be used with JSON Schema output"))
+		})
+	})
+
+	It("should not allow a header file with JSON Schema output", func() {
+		_, err := NewGenerator([]string{testDataPackage + "json"}, nil, "testdata/header.md", "", "", WithOutputFormat(OutputFormatJSONSchema))
+		Expect(err).To(MatchError("a header file cannot be used with JSON Schema output"))
+	})
+
+	It("should not allow an unknown profile", func() {
+		_, err := NewGenerator([]string{testDataPackage + "json"}, nil, "", "", "", WithProfile("hugo"))
+		Expect(err).To(MatchError(`invalid option: unknown profile "hugo", expected "docusaurus"`))
+	})
+
+	It("should not allow a profile with JSON Schema output", func() {
+		_, err := NewGenerator([]string{testDataPackage + "json"}, nil, "", "", "", WithProfile(ProfileDocusaurus), WithOutputFormat(OutputFormatJSONSchema))
+		Expect(err).To(MatchError("a profile cannot be used with JSON Schema output"))
+	})
+
+	It("should not allow invalid front matter", func() {
+		_, err := NewGenerator([]string{testDataPackage + "json"}, nil, "", "", "", WithFrontMatter([]string{"sidebar_position"}))
+		Expect(err).To(MatchError(`invalid option: invalid front matter "sidebar_position", expected <key>=<value>`))
+	})
+
+	It("should default the front matter id of the docusaurus profile to the output file name", func() {
+		gen, err := NewGenerator([]string{testDataPackage + "json"}, nil, "", "docs/configuration.mdx", "", WithProfile(ProfileDocusaurus), WithFrontMatter([]string{"title=Configuration"}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gen.(*generator).frontMatter).To(Equal(frontMatter{{Key: "id", Value: "configuration"}, {Key: "title", Value: "Configuration"}}))
+	})
+
+	It("should not allow an unknown output format", func() {
+		_, err := NewGenerator([]string{testDataPackage + "json"}, nil, "", "", "", WithOutputFormat("html"))
+		Expect(err).To(MatchError(`invalid option: unknown output format "html", expected one of "markdown", "mdx", "jsonschema"`))
+	})
+
+	It("should fail when a defaults function cannot be found", func() {
+		gen, err := NewGenerator([]string{testDataPackage + "defaultsfunc"}, nil, "", "", "", WithDefaultsFuncs([]string{"Missing"}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gen.Run()).To(MatchError(`unable to load types: could not read defaults: defaults function "Missing" not found in the loaded packages`))
+	})
+
+	It("should fail when a validation marker cannot be parsed", func() {
+		gen, err := NewGenerator([]string{testDataPackage + "validationinvalid"}, nil, "", "", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gen.Run()).To(MatchError(`unable to load types: invalid validation marker on ` + testDataPackage + `validationinvalid.Upstream.Weight: Minimum must be a number, got "one"`))
+	})
+
+	It("should not allow an unknown minimum stability", func() {
+		_, err := NewGenerator([]string{testDataPackage + "stability"}, nil, "", "", "", WithMinStability("experimental"))
+		Expect(err).To(MatchError(`invalid option: unknown stability "experimental", expected one of "alpha", "beta", "stable"`))
+	})
+
+	It("should not allow an empty struct tag key in the tag priority", func() {
+		_, err := NewGenerator([]string{testDataPackage + "tags"}, nil, "", "", "", WithTagPriority([]string{"mapstructure", "", "json"}))
+		Expect(err).To(MatchError(`invalid option: invalid tag priority "mapstructure,,json", expected a list of struct tag keys such as json,yaml`))
+	})
+
+	It("should not allow a common type without a name", func() {
+		_, err := NewGenerator([]string{testDataPackage + "json"}, nil, "", "", "", WithCommonTypes([]string{"net/url.URL="}))
+		Expect(err).To(MatchError(`invalid option: invalid common type "net/url.URL=", expected <package path>.<type>=<name>`))
+	})
 })
 
 // prettyPrintDiff prints the diff for the file out as if it were a git diff.
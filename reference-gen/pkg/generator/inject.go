@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	// DefaultBeginMarker marks the start of the generated content within a document.
+	DefaultBeginMarker = "<!-- reference-gen:begin -->"
+	// DefaultEndMarker marks the end of the generated content within a document.
+	DefaultEndMarker = "<!-- reference-gen:end -->"
+
+	generatedSectionWarning    = "<!--- THIS SECTION IS AUTOGENERATED!!! DO NOT EDIT!!! -->\n"
+	generatedSectionWarningMDX = "{/* THIS SECTION IS AUTOGENERATED!!! DO NOT EDIT!!! */}\n"
+)
+
+// markers are the comments in an existing document between which the
+// generated content is injected.
+type markers struct {
+	begin string
+	end   string
+}
+
+// injectBetweenMarkers replaces everything between the begin and end markers
+// in the document with the content given.
+// Each marker must appear exactly once, with the begin marker before the end marker.
+func injectBetweenMarkers(doc, content []byte, m markers) ([]byte, error) {
+	begin, err := findMarker(doc, m.begin)
+	if err != nil {
+		return nil, err
+	}
+	end, err := findMarker(doc, m.end)
+	if err != nil {
+		return nil, err
+	}
+	if end < begin {
+		return nil, fmt.Errorf("end marker %q appears before begin marker %q", m.end, m.begin)
+	}
+
+	var out bytes.Buffer
+	_, _ = out.Write(doc[:begin+len(m.begin)])
+	_, _ = out.WriteString("\n")
+	_, _ = out.Write(bytes.TrimRight(content, "\n"))
+	// Leave a blank line so that the end marker is not part of the last block.
+	_, _ = out.WriteString("\n\n")
+	_, _ = out.Write(doc[end:])
+
+	return out.Bytes(), nil
+}
+
+// findMarker returns the position of the marker, which must appear exactly once.
+func findMarker(doc []byte, marker string) (int, error) {
+	switch count := bytes.Count(doc, []byte(marker)); count {
+	case 0:
+		return 0, fmt.Errorf("marker %q not found", marker)
+	case 1:
+		return bytes.Index(doc, []byte(marker)), nil
+	default:
+		return 0, fmt.Errorf("marker %q found %d times, expected it once", marker, count)
+	}
+}
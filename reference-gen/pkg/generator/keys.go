@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"k8s.io/gengo/v2/types"
+	"k8s.io/klog/v2"
+)
+
+// configKey is a key of the configuration, at the path from a root type.
+type configKey struct {
+	Path   string
+	Member *types.Member
+}
+
+// configKeys lists every key of the configuration that can be reached from
+// the root types, sorted by path.
+func (i *memberIndex) configKeys(typeList []*types.Type) []configKey {
+	var out []configKey
+	for _, t := range visibleTypes(sortTypes(append([]*types.Type{}, typeList...))) {
+		for j := range t.Members {
+			m := &t.Members[j]
+			if hideMember(*m, i.priority) || fieldEmbedded(*m, i.priority) {
+				// The keys of embedded members are listed with their own type.
+				continue
+			}
+			for _, path := range i.paths[m] {
+				out = append(out, configKey{Path: path, Member: m})
+			}
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool {
+		return out[a].Path < out[b].Path
+	})
+	return out
+}
+
+// keyLinkFunc constructs a keyLink function for the template, linking from
+// the key index to the member in the reference.
+func keyLinkFunc(members *memberIndex, base string) func(m *types.Member) string {
+	return func(m *types.Member) string {
+		link := members.link(m)
+		if link == "" {
+			return ""
+		}
+		return base + link
+	}
+}
+
+// keyIndexLinkBase returns the path from the directory of the key index to
+// the reference, which links to members are relative to. A key index is only
+// allowed alongside a reference written to a file or directory.
+func (g *generator) keyIndexLinkBase() string {
+	target := g.outputFileName
+	if g.outputDirectory != "" {
+		target = g.outputDirectory
+	}
+	if g.keyIndexFileName == "" || target == "" {
+		return ""
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(g.keyIndexFileName), target)
+	if err != nil {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+	if g.outputDirectory != "" {
+		return rel + "/"
+	}
+	return rel
+}
+
+// renderKeyIndex renders the index of the configuration keys using the
+// key_index template.
+func (g *generator) renderKeyIndex(typesToRender map[*types.Type][]*types.Type) ([]byte, error) {
+	typeList := createTypeList(typesToRender)
+	knownTypes := newTypeIndex(typeList, g.commonTypes, g.tagPriority)
+	if g.outputDirectory != "" {
+		knownTypes.splitFiles(indexFileBase, g.fileExtension())
+	}
+
+	t, err := g.buildTemplate(typesToRender, typeList, knownTypes)
+	if err != nil {
+		return nil, fmt.Errorf("error building template: %v", err)
+	}
+
+	b := &bytes.Buffer{}
+	if g.profile != nil && g.profile.frontMatterID {
+		if err := t.ExecuteTemplate(b, "front_matter", frontMatter{{Key: "id", Value: fileID(g.keyIndexFileName)}}); err != nil {
+			return nil, fmt.Errorf("error executing template: %v", err)
+		}
+	}
+	b.WriteString(g.generatedWarning(false))
+	if err := t.ExecuteTemplate(b, "key_index", map[string]interface{}{
+		"types": typeList,
+	}); err != nil {
+		return nil, fmt.Errorf("error executing template: %v", err)
+	}
+	return b.Bytes(), nil
+}
+
+// runKeyIndex renders the key index and writes it to its file, or checks the
+// existing file against it.
+func (g *generator) runKeyIndex(typesToRender map[*types.Type][]*types.Type) error {
+	content, err := g.renderKeyIndex(typesToRender)
+	if err != nil {
+		return fmt.Errorf("error rendering key index: %v", err)
+	}
+
+	if g.checkOnly {
+		// Return the error unwrapped so that callers can inspect the diff.
+		return checkFile(g.keyIndexFileName, content)
+	}
+
+	if err := os.WriteFile(g.keyIndexFileName, content, 0600); err != nil {
+		return fmt.Errorf("error writing key index: could not write file %q: %v", g.keyIndexFileName, err)
+	}
+	klog.Infof("Key index written to %q", g.keyIndexFileName)
+	return nil
+}
@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/gengo/v2/types"
+)
+
+const (
+	// StandardLibraryPackages is the package pattern matching all packages
+	// of the Go standard library.
+	StandardLibraryPackages = "std"
+
+	// DefaultExternalLinkPattern links to the documentation of a type on pkg.go.dev.
+	DefaultExternalLinkPattern = "https://pkg.go.dev/{{path}}#{{name}}"
+)
+
+// externalLinks maps package patterns to URL patterns for the documentation
+// of types outside of the loaded packages.
+//
+// A package pattern is either an exact package path, a path prefix ending in
+// /* that matches any package below the prefix, or "std" for the standard
+// library. When several patterns match, the most specific pattern is used.
+//
+// URL patterns may contain the placeholders {{path}}, {{package}} and {{name}},
+// which are replaced by the package path, package name and type name.
+// An empty URL pattern disables links for the matching packages.
+type externalLinks map[string]string
+
+// defaultExternalLinks links the standard library to pkg.go.dev.
+func defaultExternalLinks() externalLinks {
+	return externalLinks{
+		StandardLibraryPackages: DefaultExternalLinkPattern,
+	}
+}
+
+// add adds a rule in the form <package pattern>=<url pattern>.
+func (e externalLinks) add(rule string) error {
+	pattern, url, ok := strings.Cut(rule, "=")
+	if !ok || pattern == "" {
+		return fmt.Errorf("invalid external link %q, expected <package pattern>=<url pattern>", rule)
+	}
+	e[strings.TrimSpace(pattern)] = strings.TrimSpace(url)
+	return nil
+}
+
+// loadFile adds the rules from a YAML file mapping package patterns to URL patterns.
+func (e externalLinks) loadFile(fileName string) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+
+	rules := make(map[string]string)
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("error parsing file %q: %v", fileName, err)
+	}
+	for pattern, url := range rules {
+		e[pattern] = url
+	}
+	return nil
+}
+
+// withoutPackages returns a copy of the rules that does not link the types
+// of the packages. Types of the loaded packages that are not documented, such
+// as hidden types, must not be linked to external documentation.
+func (e externalLinks) withoutPackages(pkgs []*types.Package) externalLinks {
+	out := make(externalLinks, len(e)+len(pkgs))
+	for pattern, url := range e {
+		out[pattern] = url
+	}
+	for _, pkg := range pkgs {
+		// Exact patterns are the most specific, so override any other rule.
+		out[pkg.Path] = ""
+	}
+	return out
+}
+
+// linkFor returns the link to the documentation of the type, or an empty
+// string if no rule matches the package of the type.
+func (e externalLinks) linkFor(t *types.Type) string {
+	pkgPath := t.Name.Package
+	if pkgPath == "" {
+		// Builtin and unnamed types have no documentation to link to.
+		return ""
+	}
+
+	url, matched := "", -1
+	for pattern, u := range e {
+		if specificity := matchPackagePattern(pattern, pkgPath); specificity > matched {
+			url, matched = u, specificity
+		}
+	}
+	if url == "" {
+		return ""
+	}
+
+	return strings.NewReplacer(
+		"{{path}}", pkgPath,
+		"{{package}}", path.Base(pkgPath),
+		"{{name}}", t.Name.Name,
+	).Replace(url)
+}
+
+// matchPackagePattern determines how specifically the pattern matches the
+// package path. Returns -1 when the pattern does not match.
+func matchPackagePattern(pattern, pkgPath string) int {
+	switch {
+	case pattern == StandardLibraryPackages:
+		// Standard library packages have no dot in their first path element.
+		if !strings.Contains(strings.SplitN(pkgPath, "/", 2)[0], ".") {
+			return 0
+		}
+	case strings.HasSuffix(pattern, "/*"):
+		prefix := strings.TrimSuffix(pattern, "*")
+		if strings.HasPrefix(pkgPath+"/", prefix) {
+			return len(prefix)
+		}
+	case pattern == pkgPath:
+		// Exact matches are more specific than any prefix of the path,
+		// including a prefix of the path followed by /*.
+		return len(pattern) + 2
+	}
+	return -1
+}
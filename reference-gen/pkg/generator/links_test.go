@@ -0,0 +1,43 @@
+package generator
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/gengo/v2/types"
+)
+
+var _ = Describe("External links", func() {
+	var links externalLinks
+
+	BeforeEach(func() {
+		links = defaultExternalLinks()
+		Expect(links.add("k8s.io/apimachinery/*=https://pkg.go.dev/{{path}}#{{name}}")).To(Succeed())
+		Expect(links.add("k8s.io/apimachinery/pkg/util/*=")).To(Succeed())
+		Expect(links.add("github.com/example/project=https://example.com/{{package}}/{{name}}")).To(Succeed())
+		Expect(links.add("github.com/example/*=https://example.com/other")).To(Succeed())
+	})
+
+	DescribeTable("should link to the most specific matching rule", func(pkgPath, name, expectedLink string) {
+		t := &types.Type{Name: types.Name{Package: pkgPath, Name: name}}
+		Expect(links.linkFor(t)).To(Equal(expectedLink))
+	},
+		Entry("standard library", "net/url", "URL", "https://pkg.go.dev/net/url#URL"),
+		Entry("builtin", "", "string", ""),
+		Entry("package below a prefix", "k8s.io/apimachinery/pkg/apis/meta/v1", "Duration", "https://pkg.go.dev/k8s.io/apimachinery/pkg/apis/meta/v1#Duration"),
+		Entry("package disabled by a more specific prefix", "k8s.io/apimachinery/pkg/util/intstr", "IntOrString", ""),
+		Entry("exact package over a prefix", "github.com/example/project", "Options", "https://example.com/project/Options"),
+		Entry("package below the exact package", "github.com/example/project/sub", "Options", "https://example.com/other"),
+		Entry("package without a rule", "github.com/unknown/project", "Options", ""),
+	)
+
+	It("should not link types of the loaded packages", func() {
+		local := links.withoutPackages([]*types.Package{{Path: "github.com/example/project"}})
+		Expect(local.linkFor(&types.Type{Name: types.Name{Package: "github.com/example/project", Name: "Options"}})).To(BeEmpty())
+		Expect(local.linkFor(&types.Type{Name: types.Name{Package: "github.com/example/project/sub", Name: "Options"}})).To(Equal("https://example.com/other"))
+		Expect(links.linkFor(&types.Type{Name: types.Name{Package: "github.com/example/project", Name: "Options"}})).To(Equal("https://example.com/project/Options"))
+	})
+
+	It("should reject invalid rules", func() {
+		Expect(links.add("https://example.com")).To(MatchError(`invalid external link "https://example.com", expected <package pattern>=<url pattern>`))
+	})
+})
@@ -0,0 +1,310 @@
+package generator
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/gengo/v2/types"
+)
+
+// memberIndex holds a unique anchor for each member of the documented types,
+// along with the config paths at which the member can be set and its default
+// value at each of them.
+// Members are known by their address within the members of their type, which
+// templates pass to functions taking a *types.Member.
+// The members of an embedded type belong to the type embedding them, so each
+// embedded member has a view of the embedded type with members of its own.
+type memberIndex struct {
+	knownTypes *typeIndex
+	// priority is the tag priority that members are named and embedded by.
+	priority []string
+	owners   map[*types.Member]*types.Type
+	// declaring maps the members to the type declaring them, or its view,
+	// whose tags decide the names of untagged members.
+	declaring map[*types.Member]*types.Type
+	views     map[*types.Member]*types.Type
+	// origins maps the members of views to the members of the embedded type.
+	origins map[*types.Member]*types.Member
+	paths   map[*types.Member][]string
+	// defaults looks up the default values of the members at their paths.
+	defaults     memberDefaults
+	pathDefaults map[*types.Member][]pathDefault
+	// reachable caches the types reachable from the type of each member, to
+	// find the members that refer back to their own type.
+	reachable map[*types.Type]typeSet
+}
+
+func newMemberIndex(typeList []*types.Type, references map[*types.Type][]*types.Type, knownTypes *typeIndex, priority []string, defaults memberDefaults) *memberIndex {
+	index := &memberIndex{
+		knownTypes:   knownTypes,
+		priority:     priority,
+		owners:       make(map[*types.Member]*types.Type),
+		declaring:    make(map[*types.Member]*types.Type),
+		views:        make(map[*types.Member]*types.Type),
+		origins:      make(map[*types.Member]*types.Member),
+		paths:        make(map[*types.Member][]string),
+		defaults:     defaults,
+		pathDefaults: make(map[*types.Member][]pathDefault),
+		reachable:    make(map[*types.Type]typeSet),
+	}
+	for _, t := range typeList {
+		index.addOwner(t, t, newTypeSetFromList([]*types.Type{t}))
+	}
+
+	for _, group := range tableOfContents(typeList, references, knownTypes) {
+		index.addPaths(group.Root, "", newTypeSetFromList([]*types.Type{group.Root}), []string{group.Root.Name.String()})
+	}
+	for m, paths := range index.paths {
+		// Roots sharing a type reach its members at the same path.
+		sort.Strings(paths)
+		index.paths[m] = slices.Compact(paths)
+	}
+	for m, defaults := range index.pathDefaults {
+		sort.SliceStable(defaults, func(a, b int) bool {
+			return defaults[a].Path < defaults[b].Path
+		})
+		index.pathDefaults[m] = slices.Compact(defaults)
+	}
+	return index
+}
+
+// addOwner records the owner of the members of the type, creating a view of
+// each embedded type for the members it adds to the owner.
+func (i *memberIndex) addOwner(t, owner *types.Type, embedding typeSet) {
+	for j := range t.Members {
+		m := &t.Members[j]
+		i.owners[m] = owner
+		i.declaring[m] = t
+		if !fieldEmbedded(*m, i.priority) {
+			continue
+		}
+		embedded := tryDereference(m.Type)
+		if embedding.has(embedded) {
+			// A type may embed a pointer to itself, whose members are already
+			// those of the owner, so its view has no members of its own.
+			i.views[m] = &types.Type{Name: embedded.Name, Kind: embedded.Kind}
+			continue
+		}
+		view := *embedded
+		view.Members = append([]types.Member{}, embedded.Members...)
+		for k := range view.Members {
+			i.origins[&view.Members[k]] = &embedded.Members[k]
+		}
+		i.views[m] = &view
+		embedding.add(embedded)
+		i.addOwner(&view, owner, embedding)
+		delete(embedding, embedded)
+	}
+}
+
+// embedded returns the type embedded by the member, as a view whose members
+// belong to the type embedding it.
+func (i *memberIndex) embedded(m *types.Member) *types.Type {
+	if view, ok := i.views[m]; ok {
+		return view
+	}
+	return tryDereference(m.Type)
+}
+
+// addPaths adds the paths of the members of the type, below the prefix given.
+// Types already on the path are not followed again, so that recursive types
+// end the path instead of repeating forever.
+// The keys name the fields through which the type is reached, to look up
+// the defaults of its members, see memberDefaults.valueAt.
+func (i *memberIndex) addPaths(t *types.Type, prefix string, onPath typeSet, keys []string) {
+	for j := range t.Members {
+		m := &t.Members[j]
+		if hideMember(*m, i.priority) {
+			continue
+		}
+		if fieldEmbedded(*m, i.priority) {
+			// Embedded members are set at the level of the type embedding them.
+			if view, ok := i.views[m]; ok {
+				i.addPaths(view, prefix, onPath, fieldKeys(keys, m, view))
+			}
+			continue
+		}
+
+		path := fieldName(*m, t, i.priority)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		// The path also applies to the member in the section of its own type.
+		value := pathDefault{Path: path, Value: i.defaults.valueAt(*m, keys)}
+		for member := m; member != nil; member = i.origins[member] {
+			i.paths[member] = append(i.paths[member], path)
+			i.pathDefaults[member] = append(i.pathDefaults[member], value)
+		}
+
+		elem, suffix := configElem(m.Type)
+		if !i.knownTypes.has(elem) || onPath.has(elem) {
+			continue
+		}
+		elemKeys := []string{elem.Name.String()}
+		if suffix == "" {
+			// Defaults are only read from literals nested directly within
+			// each other, not from within slices or maps.
+			elemKeys = fieldKeys(keys, m, elem)
+		}
+		onPath.add(elem)
+		i.addPaths(elem, path+suffix, onPath, elemKeys)
+		delete(onPath, elem)
+	}
+}
+
+// fieldKeys returns the keys naming the fields through which the type of the
+// member is reached, from the keys of the type the member belongs to.
+func fieldKeys(keys []string, m *types.Member, t *types.Type) []string {
+	out := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		out = append(out, key+"."+m.Name)
+	}
+	return append(out, t.Name.String())
+}
+
+// configElem returns the type whose members are set below a member of the
+// type given, along with the suffix of the path to those members.
+// Slices are suffixed with [] and map values with .* for any key.
+func configElem(t *types.Type) (*types.Type, string) {
+	var suffix strings.Builder
+	for !isNamedType(t) {
+		switch t.Kind {
+		case types.Pointer:
+			t = t.Elem
+		case types.Slice, types.Array:
+			suffix.WriteString("[]")
+			t = t.Elem
+		case types.Map:
+			suffix.WriteString(".*")
+			t = t.Elem
+		default:
+			// Channels and functions have no members to set.
+			return t, suffix.String()
+		}
+	}
+	return t, suffix.String()
+}
+
+// anchor returns the unique anchor of the member, from the anchor of its type
+// and its field name. Members of types that are not documented have no anchor.
+func (i *memberIndex) anchor(m *types.Member) string {
+	owner, ok := i.owners[m]
+	if !ok || !i.knownTypes.has(owner) {
+		return ""
+	}
+	return i.knownTypes.memberAnchor(owner, i.fieldName(m))
+}
+
+// fieldName returns the field name of the member, see fieldName.
+func (i *memberIndex) fieldName(m *types.Member) string {
+	return fieldName(*m, i.declaring[m], i.priority)
+}
+
+// link returns the link to the row of the member in the table of its type.
+func (i *memberIndex) link(m *types.Member) string {
+	anchor := i.anchor(m)
+	if anchor == "" {
+		return ""
+	}
+	if file, ok := i.knownTypes.files[i.owners[m]]; ok {
+		return file + "#" + anchor
+	}
+	return "#" + anchor
+}
+
+// lookup returns the documented member named by its type and field name,
+// such as Cookie.secret, or by one of its config paths, or nil if there is no
+// such member. Members of embedded types are named by the type embedding
+// them, and are found in the section of either type by their path, so the
+// member with the first link is returned.
+func (i *memberIndex) lookup(name string) *types.Member {
+	var found *types.Member
+	for m, owner := range i.owners {
+		if hideMember(*m, i.priority) || fieldEmbedded(*m, i.priority) || i.link(m) == "" {
+			continue
+		}
+		named := i.knownTypes.name(owner)+"."+i.fieldName(m) == name
+		if !named && !slices.Contains(i.paths[m], name) {
+			continue
+		}
+		if found == nil || i.link(m) < i.link(found) {
+			found = m
+		}
+	}
+	return found
+}
+
+// configPaths returns the config paths at which the member can be set, from
+// each of the root types of the configuration.
+func (i *memberIndex) configPaths(m *types.Member) []string {
+	return i.paths[m]
+}
+
+// defaultValue returns the default value of the member when it is the same at
+// each of the config paths of the member, or an empty string.
+func (i *memberIndex) defaultValue(m *types.Member) string {
+	defaults, ok := i.pathDefaults[m]
+	if !ok {
+		return i.defaults.value(*m)
+	}
+	for _, d := range defaults[1:] {
+		if d.Value != defaults[0].Value {
+			return ""
+		}
+	}
+	return defaults[0].Value
+}
+
+// defaultsByPath returns the default values of the member at each of its
+// config paths that has one, when the defaults differ between its paths.
+func (i *memberIndex) defaultsByPath(m *types.Member) []pathDefault {
+	if i.defaultValue(m) != "" {
+		return nil
+	}
+	var out []pathDefault
+	for _, d := range i.pathDefaults[m] {
+		if d.Value != "" {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// anyDefaults determines if any visible member of the types has a default
+// value, at any of its paths.
+func (i *memberIndex) anyDefaults(typeList []*types.Type) bool {
+	for _, t := range visibleTypes(typeList) {
+		for j := range t.Members {
+			m := &t.Members[j]
+			if hideMember(*m, i.priority) {
+				continue
+			}
+			if i.defaultValue(m) != "" || len(i.defaultsByPath(m)) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recursive determines if the member refers back to the type it belongs to,
+// either directly or through other types, so that the configuration below
+// the member may repeat. Members of embedded types also refer back to the
+// type embedding them.
+func (i *memberIndex) recursive(m *types.Member) bool {
+	if fieldEmbedded(*m, i.priority) {
+		return false
+	}
+	reachable, ok := i.reachable[m.Type]
+	if !ok {
+		reachable = reachableTypes(newTypeSetFromList(referencedTypes(m.Type)), func(*types.Type) bool { return true }, i.priority)
+		i.reachable[m.Type] = reachable
+	}
+	for member := m; member != nil; member = i.origins[member] {
+		if owner, ok := i.owners[member]; ok && reachable.has(owner) {
+			return true
+		}
+	}
+	return false
+}
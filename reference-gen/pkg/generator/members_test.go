@@ -0,0 +1,27 @@
+package generator
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/gengo/v2/types"
+)
+
+var _ = Describe("Member anchors", func() {
+	It("should not give a member the anchor of a type", func() {
+		foo := &types.Type{
+			Name:    types.Name{Package: "github.com/example/project/options", Name: "Foo"},
+			Kind:    types.Struct,
+			Members: []types.Member{{Name: "Bar", Type: types.String, Tags: `json:"bar"`}},
+		}
+		// Types with the same name are anchored by their package, as foo-bar.
+		fooBar := &types.Type{Name: types.Name{Package: "github.com/example/project/foo", Name: "Bar"}, Kind: types.Struct}
+		otherBar := &types.Type{Name: types.Name{Package: "github.com/example/project/other", Name: "Bar"}, Kind: types.Struct}
+		typeList := []*types.Type{foo, fooBar, otherBar}
+
+		knownTypes := newTypeIndex(typeList, nil, defaultTagPriority)
+		Expect(knownTypes.anchor(fooBar)).To(Equal("foo-bar"))
+
+		members := newMemberIndex(typeList, map[*types.Type][]*types.Type{}, knownTypes, defaultTagPriority, memberDefaults{})
+		Expect(members.anchor(&foo.Members[0])).To(Equal("foo-bar-2"))
+	})
+})
@@ -0,0 +1,272 @@
+package generator
+
+import (
+	"fmt"
+)
+
+const (
+	// OutputFormatMarkdown renders the references as Markdown using the templates.
+	OutputFormatMarkdown = "markdown"
+	// OutputFormatMDX renders the references as MDX using the templates.
+	// It differs from OutputFormatMarkdown in how text is escaped and in the
+	// syntax of the generated comments.
+	OutputFormatMDX = "mdx"
+	// OutputFormatJSONSchema renders the references as a JSON Schema document.
+	OutputFormatJSONSchema = "jsonschema"
+)
+
+// Option configures optional behaviour of the generator.
+type Option func(*generator) error
+
+// WithOutputFormat sets the format of the rendered output.
+// Defaults to OutputFormatMarkdown.
+func WithOutputFormat(format string) Option {
+	return func(g *generator) error {
+		switch format {
+		case "":
+			g.outputFormat = OutputFormatMarkdown
+		case OutputFormatMarkdown, OutputFormatMDX, OutputFormatJSONSchema:
+			g.outputFormat = format
+		default:
+			return fmt.Errorf("unknown output format %q, expected one of %q, %q, %q", format, OutputFormatMarkdown, OutputFormatMDX, OutputFormatJSONSchema)
+		}
+		return nil
+	}
+}
+
+// WithProfile adapts the output for a documentation tool, such as
+// ProfileDocusaurus. The profile sets the output format, and its templates
+// override the default templates of the same name.
+func WithProfile(name string) Option {
+	return func(g *generator) error {
+		if name == "" {
+			g.profile = nil
+			return nil
+		}
+		p, ok := profiles[name]
+		if !ok {
+			return fmt.Errorf("unknown profile %q, expected %q", name, ProfileDocusaurus)
+		}
+		g.profile = &p
+		return nil
+	}
+}
+
+// WithFrontMatter adds fields to the front matter of the document.
+// Each field is in the form <key>=<value>, for example sidebar_position=3.
+func WithFrontMatter(fields []string) Option {
+	return func(g *generator) error {
+		for _, field := range fields {
+			if err := g.frontMatter.add(field); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithOutputDirectory renders each type into a file of its own in the
+// directory, along with an index of the types. Files written by a previous
+// run for types that are no longer documented are removed.
+func WithOutputDirectory(dir string) Option {
+	return func(g *generator) error {
+		g.outputDirectory = dir
+		return nil
+	}
+}
+
+// WithTableOfContents adds a table of contents, listing the types grouped by
+// the root types they are reachable from, to the start of the output.
+func WithTableOfContents(toc bool) Option {
+	return func(g *generator) error {
+		g.tableOfContents = toc
+		return nil
+	}
+}
+
+// WithKeyIndexFile writes an index of every configuration key, as the path
+// from the root types, to the file in addition to the reference.
+func WithKeyIndexFile(fileName string) Option {
+	return func(g *generator) error {
+		g.keyIndexFileName = fileName
+		return nil
+	}
+}
+
+// WithOmitEmptyOptional documents members with the omitempty option in their
+// json or yaml tag as optional, as well as members marked +optional.
+func WithOmitEmptyOptional(omitEmptyOptional bool) Option {
+	return func(g *generator) error {
+		g.omitEmptyOptional = omitEmptyOptional
+		return nil
+	}
+}
+
+// WithTagPriority sets the order in which the struct tags of fields are used
+// to name and embed them, such as mapstructure,toml,json. Fields are
+// documented by the first tag of the priority that they have.
+func WithTagPriority(priority []string) Option {
+	return func(g *generator) error {
+		if len(priority) == 0 {
+			return nil
+		}
+		if err := checkTagPriority(priority); err != nil {
+			return err
+		}
+		g.tagPriority = priority
+		return nil
+	}
+}
+
+// WithTagMismatchWarnings logs a warning for each documented field whose
+// struct tags of the tag priority name it differently.
+func WithTagMismatchWarnings(warn bool) Option {
+	return func(g *generator) error {
+		g.warnTagMismatch = warn
+		return nil
+	}
+}
+
+// WithCheckOnly makes the generator compare the rendered output with the
+// existing output file instead of overwriting it.
+func WithCheckOnly(check bool) Option {
+	return func(g *generator) error {
+		g.checkOnly = check
+		return nil
+	}
+}
+
+// WithInjectMarkers makes the generator inject the rendered output between
+// the begin and end markers of the existing output file, leaving the rest of
+// the file untouched.
+// Empty markers default to DefaultBeginMarker and DefaultEndMarker.
+func WithInjectMarkers(begin, end string) Option {
+	return func(g *generator) error {
+		if begin == "" {
+			begin = DefaultBeginMarker
+		}
+		if end == "" {
+			end = DefaultEndMarker
+		}
+		if begin == end {
+			return fmt.Errorf("begin and end markers must be different, got %q", begin)
+		}
+		g.markers = &markers{begin: begin, end: end}
+		return nil
+	}
+}
+
+// WithBuildTags sets the build tags used to select the files of the package.
+func WithBuildTags(tags []string) Option {
+	return func(g *generator) error {
+		g.buildTags = tags
+		return nil
+	}
+}
+
+// WithExternalLinks adds rules for linking types outside of the loaded
+// packages to their documentation.
+// Each rule is in the form <package pattern>=<url pattern>, for example
+// k8s.io/apimachinery/*=https://pkg.go.dev/{{path}}#{{name}}.
+// The standard library is linked to pkg.go.dev unless overridden with a rule
+// for the "std" package pattern.
+func WithExternalLinks(rules []string) Option {
+	return func(g *generator) error {
+		for _, rule := range rules {
+			if err := g.externalLinks.add(rule); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithExternalLinksFile adds rules for linking types outside of the loaded
+// packages from a YAML file mapping package patterns to URL patterns.
+func WithExternalLinksFile(fileName string) Option {
+	return func(g *generator) error {
+		if fileName == "" {
+			return nil
+		}
+		if err := g.externalLinks.loadFile(fileName); err != nil {
+			return fmt.Errorf("error loading external links: %v", err)
+		}
+		return nil
+	}
+}
+
+// WithCommonTypes adds overrides for how types are displayed.
+// Each override is in the form <package path>.<type>=<name>, for example
+// net/url.URL=string (URL).
+func WithCommonTypes(overrides []string) Option {
+	return func(g *generator) error {
+		for _, override := range overrides {
+			if err := g.commonTypes.add(override); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithCommonTypesFile adds overrides for how types are displayed from a YAML
+// file mapping type identifiers to a name, or a name, description and link.
+func WithCommonTypesFile(fileName string) Option {
+	return func(g *generator) error {
+		if fileName == "" {
+			return nil
+		}
+		if err := g.commonTypes.loadFile(fileName); err != nil {
+			return fmt.Errorf("error loading common types: %v", err)
+		}
+		return nil
+	}
+}
+
+// WithDefaultTag sets the struct tag that default values of members are read
+// from when a member has no +reference-gen:default marker.
+// An empty tag only reads default values from markers.
+// Defaults to DefaultDefaultTag.
+func WithDefaultTag(tag string) Option {
+	return func(g *generator) error {
+		g.defaults.tag = tag
+		return nil
+	}
+}
+
+// WithDefaultsFuncs reads the default values of members from the composite
+// literals returned by the functions. Functions are given by name, or
+// qualified with their package path as <package path>.<function>.
+func WithDefaultsFuncs(funcs []string) Option {
+	return func(g *generator) error {
+		g.defaults.funcs = append(g.defaults.funcs, funcs...)
+		return nil
+	}
+}
+
+// WithMinStability removes the types and members that are less stable than the
+// stability given, along with any types only reachable through them.
+// Defaults to StabilityAlpha, which documents everything.
+func WithMinStability(stability string) Option {
+	return func(g *generator) error {
+		if stability == "" {
+			stability = StabilityAlpha
+		}
+		if _, ok := stabilityLevels[stability]; !ok {
+			return fmt.Errorf("unknown stability %q, expected one of %q, %q, %q", stability, StabilityAlpha, StabilityBeta, StabilityStable)
+		}
+		g.visibility.minStability = stability
+		return nil
+	}
+}
+
+// WithAudiences documents the types and members marked for the audiences, with
+// a +reference-gen:audience marker, in addition to those without a marker.
+func WithAudiences(audiences []string) Option {
+	return func(g *generator) error {
+		for _, audience := range audiences {
+			g.visibility.audiences.add(audience)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ProfileDocusaurus renders MDX for Docusaurus, with front matter and
+	// admonitions for deprecated and unstable types.
+	ProfileDocusaurus = "docusaurus"
+)
+
+// profile adapts the output for a documentation tool.
+type profile struct {
+	// outputFormat is the format rendered by the profile.
+	outputFormat string
+	// templates are parsed after the default templates and before the
+	// template directory, overriding the templates of the same name.
+	templates []string
+	// frontMatterID adds an id, from the output file name, to the front matter.
+	frontMatterID bool
+}
+
+var profiles = map[string]profile{
+	ProfileDocusaurus: {
+		outputFormat:  OutputFormatMDX,
+		templates:     []string{docusaurusTypeNoticesTemplate},
+		frontMatterID: true,
+	},
+}
+
+const docusaurusTypeNoticesTemplate = `
+{{ define "type_notices" }}
+{{- with deprecation .CommentLines -}}
+:::warning
+
+{{ template "deprecation" . }}
+
+:::
+
+{{ end -}}
+{{ with unstable .CommentLines -}}
+:::warning
+
+{{ if eq . "alpha" }}**Alpha:** this type may change or be removed in a future release.{{ else }}**Beta:** this type may change in a future release.{{ end }}
+
+:::
+
+{{ end -}}
+{{ end }}
+`
+
+// frontMatterField is a field of the front matter of the document.
+type frontMatterField struct {
+	Key   string
+	Value string
+}
+
+// frontMatter is the front matter of the document, in the order of the fields.
+type frontMatter []frontMatterField
+
+// add parses a field in the form <key>=<value>. A field that is already set
+// is replaced.
+func (f *frontMatter) add(field string) error {
+	key, value, ok := strings.Cut(field, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("invalid front matter %q, expected <key>=<value>", field)
+	}
+	f.set(strings.TrimSpace(key), value)
+	return nil
+}
+
+func (f *frontMatter) set(key, value string) {
+	for i := range *f {
+		if (*f)[i].Key == key {
+			(*f)[i].Value = value
+			return
+		}
+	}
+	*f = append(*f, frontMatterField{Key: key, Value: value})
+}
+
+// setDefault sets the field first in the front matter, unless it is already set.
+func (f *frontMatter) setDefault(key, value string) {
+	for _, field := range *f {
+		if field.Key == key {
+			return
+		}
+	}
+	*f = append(frontMatter{{Key: key, Value: value}}, *f...)
+}
+
+// fileID returns the name of the file without its directory and extension,
+// as used by documentation tools to identify the document.
+func fileID(fileName string) string {
+	base := filepath.Base(fileName)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// yamlValue renders the value as a YAML scalar, quoting it when needed.
+// Integers, such as a sidebar position, are kept as numbers.
+func yamlValue(value string) string {
+	if _, err := strconv.Atoi(value); err == nil {
+		return value
+	}
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return strconv.Quote(value)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
@@ -1,42 +1,109 @@
 package generator
 
 import (
+	"errors"
 	"fmt"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
 
-	"k8s.io/gengo/parser"
-	"k8s.io/gengo/types"
+	"golang.org/x/tools/go/packages"
+	"k8s.io/gengo/v2/types"
 )
 
-// loadPackage loads and parses the given package.
-func loadPackage(packageName string) (*types.Package, error) {
-	b := parser.New()
-	// the following may silently fail (turn on -v=4 to see logs)
-	if err := b.AddDir(packageName); err != nil {
-		return nil, err
+// loadPackages loads, type checks and parses the given packages.
+// Packages may be given as import paths, directories or patterns such as
+// ./pkg/apis/..., and are resolved using the module, workspace and vendoring
+// rules of the go command.
+// The packages are returned sorted by their path, along with the packages as
+// loaded by go/packages, keyed by their path, which hold their syntax.
+func loadPackages(patterns []string, buildTags []string) ([]*types.Package, map[string]*packages.Package, error) {
+	cfg := &packages.Config{
+		// Load dependencies from source, rather than relying on export data
+		// that may not match the go toolchain.
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+		BuildFlags: []string{"-tags", strings.Join(buildTags, ",")},
+		Fset:       token.NewFileSet(),
 	}
-
-	universe, err := b.FindTypes()
+	loaded, err := packages.Load(cfg, patterns...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find types for package: %v", err)
+		return nil, nil, fmt.Errorf("failed to load packages %q: %v", patterns, err)
+	}
+	if len(loaded) == 0 {
+		return nil, nil, fmt.Errorf("packages %q were not found", patterns)
+	}
+	if err := checkPackages(loaded, buildTags); err != nil {
+		return nil, nil, err
 	}
 
-	pkg := universe.Package(packageName)
-	if pkg == nil {
-		return nil, fmt.Errorf("package %q was not found by parser", packageName)
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].PkgPath < loaded[j].PkgPath
+	})
+	syntax := make(map[string]*packages.Package)
+	for _, pkg := range loaded {
+		syntax[pkg.PkgPath] = pkg
 	}
+	return buildUniverse(cfg.Fset, loaded), syntax, nil
+}
 
-	return pkg, nil
+// checkPackages checks that the packages loaded without errors, so that any
+// errors are reported with the package and file they belong to.
+// Otherwise the errors would cause types to be silently missing from the
+// output.
+func checkPackages(pkgs []*packages.Package, buildTags []string) error {
+	var errs []error
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			errs = append(errs, fmt.Errorf("package %q: %v", pkg.PkgPath, e))
+		}
+		if len(pkg.Errors) == 0 && len(pkg.GoFiles) == 0 {
+			errs = append(errs, fmt.Errorf("package %q: no Go files found with build tags %q", pkg.PkgPath, buildTags))
+		}
+	}
+	return errors.Join(errs...)
 }
 
-// isReferenceRequired determines if the type needs a reference generated.
-func isReferenceRequired(t *types.Type, requiredTypes stringSet, allReferences map[*types.Type][]*types.Type) bool {
-	if requiredTypes.has(t.Name.Name) {
-		return true
+// packageTypes collects the types of all of the packages, keyed by their
+// fully qualified name.
+func packageTypes(pkgs []*types.Package) map[string]*types.Type {
+	out := make(map[string]*types.Type)
+	for _, pkg := range pkgs {
+		for _, t := range pkg.Types {
+			out[t.Name.String()] = t
+		}
 	}
-	for _, reference := range allReferences[t] {
-		if isReferenceRequired(reference, requiredTypes, allReferences) {
+	return out
+}
+
+// isRequestedType determines if the type was requested, either by its name
+// or by its name qualified with its package name or path.
+func isRequestedType(t *types.Type, requestedTypes stringSet) bool {
+	return requestedTypes.has(t.Name.Name) ||
+		requestedTypes.has(path.Base(t.Name.Package)+"."+t.Name.Name) ||
+		requestedTypes.has(t.Name.String())
+}
+
+// isReferenceRequired determines if the type needs a reference generated,
+// because it is requested or is referenced, directly or through other types,
+// by a requested type.
+// Types that have already been visited are not followed again, so that
+// recursive types do not recurse forever.
+func isReferenceRequired(t *types.Type, requiredTypes stringSet, allReferences map[*types.Type][]*types.Type) bool {
+	visited := newTypeSetFromList([]*types.Type{t})
+	queue := []*types.Type{t}
+	for len(queue) > 0 {
+		typ := queue[0]
+		queue = queue[1:]
+		if isRequestedType(typ, requiredTypes) {
 			return true
 		}
+		for _, reference := range allReferences[typ] {
+			if !visited.has(reference) {
+				visited.add(reference)
+				queue = append(queue, reference)
+			}
+		}
 	}
 	return false
 }
@@ -67,7 +134,7 @@ func filterToPackageTypes(allReferences map[*types.Type][]*types.Type, pkgTypes
 
 // findTypeReferences converts a list of types to a map of types and types that
 // reference that type.
-func findTypeReferences(allTypes map[string]*types.Type) map[*types.Type][]*types.Type {
+func findTypeReferences(allTypes map[string]*types.Type, priority []string) map[*types.Type][]*types.Type {
 	m := make(map[*types.Type]typeSet)
 	for _, typ := range allTypes {
 		// Ensure every type is initialised, if not already
@@ -77,25 +144,30 @@ func findTypeReferences(allTypes map[string]*types.Type) map[*types.Type][]*type
 
 		// add this type to other types that it references
 		for _, member := range typ.Members {
-			if hideMember(member) {
+			if hideMember(member, priority) {
 				// Don't include a reference if the member is private.
 				continue
 			}
-			t := member.Type
-			t = tryDereference(t)
-			if _, ok := m[t]; !ok {
-				m[t] = make(typeSet)
+			for _, t := range referencedTypes(member.Type) {
+				if t == typ && fieldEmbedded(member, priority) {
+					// A type embedding itself only adds the members it has.
+					continue
+				}
+				if _, ok := m[t]; !ok {
+					m[t] = make(typeSet)
+				}
+				m[t].add(typ)
 			}
-			m[t].add(typ)
 		}
 
 		// Cater for aliases rather than structs
 		if typ.Underlying != nil {
-			t := tryDereference(typ.Underlying)
-			if _, ok := m[t]; !ok {
-				m[t] = make(typeSet)
+			for _, t := range referencedTypes(typ.Underlying) {
+				if _, ok := m[t]; !ok {
+					m[t] = make(typeSet)
+				}
+				m[t].add(typ)
 			}
-			m[t].add(typ)
 		}
 	}
 
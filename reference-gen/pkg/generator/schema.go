@@ -0,0 +1,341 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/gengo/v2/types"
+)
+
+const (
+	jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	jsonSchemaDefs  = "#/$defs/"
+
+	// durationPattern matches the duration strings accepted by time.ParseDuration.
+	durationPattern = `^[-+]?(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+$|^0$`
+)
+
+var (
+	// commonTypeSchemas maps the display names from commonTypes to their schema.
+	commonTypeSchemas = map[string]jsonSchema{
+		"duration": {Type: "string", Pattern: durationPattern},
+	}
+
+	// builtinTypeSchemas maps Go builtin type names to their schema type.
+	builtinTypeSchemas = map[string]string{
+		"bool":    "boolean",
+		"string":  "string",
+		"int":     "integer",
+		"int8":    "integer",
+		"int16":   "integer",
+		"int32":   "integer",
+		"int64":   "integer",
+		"uint":    "integer",
+		"uint8":   "integer",
+		"uint16":  "integer",
+		"uint32":  "integer",
+		"uint64":  "integer",
+		"uintptr": "integer",
+		"byte":    "integer",
+		"rune":    "integer",
+		"float32": "number",
+		"float64": "number",
+		"boolean": "boolean",
+		"integer": "integer",
+		"number":  "number",
+	}
+)
+
+// jsonSchema is the subset of a JSON Schema (draft 2020-12) document used
+// by the generator.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Comment              string                 `json:"$comment,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Deprecated           bool                   `json:"deprecated,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum,omitempty"`
+	MultipleOf           *float64               `json:"multipleOf,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	UniqueItems          bool                   `json:"uniqueItems,omitempty"`
+	ContentEncoding      string                 `json:"contentEncoding,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AnyOf                []*jsonSchema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+
+	// never marks the schema that no value is valid against, which is
+	// written as false.
+	never bool
+}
+
+// falseSchema is the schema that no value is valid against, as the
+// additionalProperties of objects whose properties are all known.
+var falseSchema = &jsonSchema{never: true}
+
+// MarshalJSON writes the schema as an object, or as false when no value is
+// valid against it.
+func (s *jsonSchema) MarshalJSON() ([]byte, error) {
+	if s.never {
+		return []byte("false"), nil
+	}
+	// The plain type does not have the method, so is marshalled as a struct.
+	type plain jsonSchema
+	return json.Marshal((*plain)(s))
+}
+
+// renderSchema renders the types as a JSON Schema document.
+// The requested types become the root of the document and every visible
+// type is added to the $defs.
+func (g *generator) renderSchema(typesToRender map[*types.Type][]*types.Type) ([]byte, error) {
+	typeList := visibleTypes(sortTypes(createTypeList(typesToRender)))
+	knownTypes := newTypeIndex(typeList, g.commonTypes, g.tagPriority)
+	members := newMemberIndex(typeList, typesToRender, knownTypes, g.tagPriority, g.defaults)
+
+	doc := &jsonSchema{
+		Schema:  jsonSchemaDraft,
+		Comment: generatedTextNotice,
+		Defs:    make(map[string]*jsonSchema),
+	}
+
+	enums := packageEnums(g.packages, g.syntax, g.commonTypes)
+
+	builder := &schemaBuilder{
+		knownTypes:        knownTypes,
+		members:           members,
+		priority:          g.tagPriority,
+		omitEmptyOptional: g.omitEmptyOptional,
+		partial:           g.partialTypes,
+	}
+
+	var roots []*jsonSchema
+	for _, typ := range typeList {
+		def := builder.definition(typ)
+		if enum, ok := enums[typ]; ok && enum.Closed {
+			// Only the values of marked enums are known to be complete.
+			def.Enum = schemaEnum(enum.Values, def)
+		}
+		doc.Defs[knownTypes.name(typ)] = def
+		if isRequestedType(typ, g.requestedTypes) {
+			roots = append(roots, schemaRef(typ, knownTypes))
+		}
+	}
+
+	switch len(roots) {
+	case 0:
+		// No root types, the document only holds the definitions.
+	case 1:
+		doc.Ref = roots[0].Ref
+	default:
+		doc.AnyOf = roots
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling schema: %v", err)
+	}
+	return append(out, '\n'), nil
+}
+
+// schemaBuilder builds the schemas of the documented types and of their
+// members.
+type schemaBuilder struct {
+	knownTypes *typeIndex
+	members    *memberIndex
+	// priority is the tag priority that members are named and embedded by.
+	priority          []string
+	omitEmptyOptional bool
+	// partial holds the types with members left out of the documentation,
+	// which still accept the properties of those members.
+	partial typeSet
+}
+
+// definition builds the $defs entry for a local type.
+func (b *schemaBuilder) definition(t *types.Type) *jsonSchema {
+	var s *jsonSchema
+	switch {
+	case aliasNameOverride(t) != "":
+		s = schemaForTypeName(aliasNameOverride(t))
+	case t.Kind == types.Struct:
+		s = b.structSchema(t)
+	case t.Underlying != nil:
+		s = b.typeSchema(t.Underlying)
+	case isCompositeType(t):
+		s = b.compositeSchema(t)
+	default:
+		s = &jsonSchema{}
+	}
+
+	s.Description = renderCommentsLF(t.CommentLines)
+	if d := deprecationOf(t.CommentLines); d != nil {
+		s.Deprecated = true
+		s.Description = d.schemaDescription(s.Description)
+	}
+	return s
+}
+
+// structSchema builds an object schema from the visible members of the struct.
+// Other properties are not allowed when all of the members are known.
+func (b *schemaBuilder) structSchema(t *types.Type) *jsonSchema {
+	s := &jsonSchema{
+		Type:       "object",
+		Properties: make(map[string]*jsonSchema),
+	}
+	if b.addMemberSchemas(s, t, newTypeSetFromList([]*types.Type{t})) {
+		s.AdditionalProperties = falseSchema
+	}
+	return s
+}
+
+// addMemberSchemas adds the visible members of the type to the object schema,
+// and returns whether all of the members of the type, and of the types it
+// embeds, are known.
+// Embedded members are flattened into the object, as they are when marshalled.
+// The embedding set holds the types already being flattened, as a type may
+// embed a pointer to itself.
+// Defaults are only added when they are the same at each path of the member,
+// as the schema of a type is shared by the paths.
+func (b *schemaBuilder) addMemberSchemas(s *jsonSchema, t *types.Type, embedding typeSet) bool {
+	known := !b.partial.has(t)
+	for i := range t.Members {
+		m := &t.Members[i]
+		if hideMember(*m, b.priority) {
+			continue
+		}
+		if fieldEmbedded(*m, b.priority) {
+			embedded := tryDereference(m.Type)
+			if !embedding.has(embedded) {
+				embedding.add(embedded)
+				known = b.addMemberSchemas(s, embedded, embedding) && known
+				delete(embedding, embedded)
+			}
+			continue
+		}
+
+		name := fieldName(*m, t, b.priority)
+		prop := b.typeSchema(m.Type)
+		desc := renderCommentsLF(m.CommentLines)
+		value := b.members.defaultValue(m)
+		constraints := memberConstraints(*m)
+		deprecated := deprecationOf(m.CommentLines)
+		if desc != "" || value != "" || len(constraints) > 0 || deprecated != nil {
+			// Copy the schema so that a shared schema is not modified.
+			p := *prop
+			p.Description = desc
+			if deprecated != nil {
+				p.Deprecated = true
+				p.Description = deprecated.schemaDescription(desc)
+			}
+			applyConstraints(&p, constraints)
+			if value != "" {
+				p.Default = schemaDefault(value, &p)
+			}
+			prop = &p
+		}
+		s.Properties[name] = prop
+
+		if !isOptionalMember(*m, b.priority, b.omitEmptyOptional) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return known
+}
+
+// typeSchema builds the schema for a type used by a member.
+// Local types are referenced from the $defs, other types are inlined.
+func (b *schemaBuilder) typeSchema(t *types.Type) *jsonSchema {
+	if b.knownTypes.has(t) {
+		return schemaRef(t, b.knownTypes)
+	}
+	if isCompositeType(t) {
+		return b.compositeSchema(t)
+	}
+
+	if common, ok := b.knownTypes.commonType(t); ok {
+		return schemaForTypeName(common.Name)
+	}
+
+	switch t.Kind {
+	case types.Builtin:
+		return schemaForTypeName(t.Name.Name)
+	case types.Struct:
+		if isAnonymousStruct(t) {
+			// Anonymous structs have no definition to refer to.
+			return b.structSchema(t)
+		}
+		return &jsonSchema{Type: "object"}
+	case types.Alias:
+		return b.typeSchema(t.Underlying)
+	default:
+		// Interfaces and any other kinds accept any value.
+		return &jsonSchema{}
+	}
+}
+
+// compositeSchema builds the schema for a composite type from the schemas
+// of the types within it.
+func (b *schemaBuilder) compositeSchema(t *types.Type) *jsonSchema {
+	switch t.Kind {
+	case types.Pointer:
+		return b.typeSchema(t.Elem)
+	case types.Slice:
+		if t.Elem.Kind == types.Builtin && t.Elem.Name.Name == "byte" {
+			// encoding/json marshals byte slices as base64 strings
+			return &jsonSchema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &jsonSchema{Type: "array", Items: b.typeSchema(t.Elem)}
+	case types.Array:
+		n := int(t.Len)
+		return &jsonSchema{Type: "array", Items: b.typeSchema(t.Elem), MinItems: &n, MaxItems: &n}
+	case types.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: b.typeSchema(t.Elem)}
+	default:
+		// Channels and functions cannot be marshalled, accept any value.
+		return &jsonSchema{}
+	}
+}
+
+// isAnonymousStruct determines if the type is a struct declared without a
+// name, such as the type of a member declared as struct{ ... }.
+func isAnonymousStruct(t *types.Type) bool {
+	return t.Kind == types.Struct && t.Name.Package == "" && strings.HasPrefix(t.Name.Name, "struct{")
+}
+
+// schemaForTypeName builds the schema for a builtin or common type display name.
+// Names that start with a builtin type, such as "string (URL)", use the schema
+// of the builtin type. Unrecognised names accept any value.
+func schemaForTypeName(name string) *jsonSchema {
+	if s, ok := commonTypeSchemas[name]; ok {
+		return &s
+	}
+	// Names may be empty, or only whitespace, when set by a marker.
+	if fields := strings.Fields(name); len(fields) > 0 {
+		if typ, ok := builtinTypeSchemas[fields[0]]; ok {
+			return &jsonSchema{Type: typ}
+		}
+	}
+	return &jsonSchema{}
+}
+
+// jsonPointerReplacer escapes a $defs key for a JSON Pointer, as the names of
+// types from packages sharing a base name hold the slashes of their import path.
+var jsonPointerReplacer = strings.NewReplacer("~", "~0", "/", "~1")
+
+// schemaRef builds a reference to the $defs entry of a local type.
+func schemaRef(t *types.Type, knownTypes *typeIndex) *jsonSchema {
+	return &jsonSchema{Ref: jsonSchemaDefs + jsonPointerReplacer.Replace(knownTypes.name(t))}
+}
@@ -0,0 +1,18 @@
+package generator
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSON Schema", func() {
+	DescribeTable("should build the schema of a type name", func(name string, expected *jsonSchema) {
+		Expect(schemaForTypeName(name)).To(Equal(expected))
+	},
+		Entry("of a builtin type", "string", &jsonSchema{Type: "string"}),
+		Entry("starting with a builtin type", "string (URL)", &jsonSchema{Type: "string"}),
+		Entry("that is not recognised", "Secret", &jsonSchema{}),
+		Entry("that is empty", "", &jsonSchema{}),
+		Entry("that is only whitespace", " \t", &jsonSchema{}),
+	)
+})
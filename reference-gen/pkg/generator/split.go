@@ -0,0 +1,208 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/gengo/v2/types"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// indexFileBase is the name, without extension, of the index of the types.
+	indexFileBase = "index"
+	// manifestFileName is the name of the file listing the files written to
+	// the output directory, so that stale files can be removed by a later run.
+	manifestFileName = ".reference-gen-manifest"
+)
+
+// renderFiles renders each visible type into a file of its own, along with an
+// index of the types and a manifest of the files.
+// The files are keyed by their name within the output directory.
+func (g *generator) renderFiles(typesToRender map[*types.Type][]*types.Type) (map[string][]byte, error) {
+	typeList := createTypeList(typesToRender)
+	knownTypes := newTypeIndex(typeList, g.commonTypes, g.tagPriority)
+	ext := g.fileExtension()
+	knownTypes.splitFiles(indexFileBase, ext)
+
+	t, err := g.buildTemplate(typesToRender, typeList, knownTypes)
+	if err != nil {
+		return nil, fmt.Errorf("error building template: %v", err)
+	}
+
+	warning := g.generatedWarning(false)
+
+	files := make(map[string][]byte)
+
+	index := &bytes.Buffer{}
+	if err := t.ExecuteTemplate(index, "front_matter", g.frontMatter); err != nil {
+		return nil, fmt.Errorf("error executing template: %v", err)
+	}
+	index.Write(g.headerText)
+	index.WriteString(warning)
+	if err := t.ExecuteTemplate(index, "index", map[string]interface{}{
+		"types":    typeList,
+		"packages": packageSections(g.packages, typeList),
+	}); err != nil {
+		return nil, fmt.Errorf("error executing template: %v", err)
+	}
+	files[indexFileBase+ext] = index.Bytes()
+
+	for _, typ := range visibleTypes(sortTypes(typeList)) {
+		name := knownTypes.files[typ]
+
+		b := &bytes.Buffer{}
+		if g.profile != nil && g.profile.frontMatterID {
+			fields := frontMatter{{Key: "id", Value: fileID(name)}, {Key: "title", Value: knownTypes.name(typ)}}
+			if err := t.ExecuteTemplate(b, "front_matter", fields); err != nil {
+				return nil, fmt.Errorf("error executing template: %v", err)
+			}
+		}
+		b.WriteString(warning)
+		if err := t.ExecuteTemplate(b, "type", typ); err != nil {
+			return nil, fmt.Errorf("error executing template for type %s: %v", knownTypes.name(typ), err)
+		}
+		files[name] = b.Bytes()
+	}
+
+	files[manifestFileName] = []byte(strings.Join(sortedFileNames(files), "\n") + "\n")
+	return files, nil
+}
+
+// fileExtension returns the extension of the files rendered in the output format.
+func (g *generator) fileExtension() string {
+	if g.outputFormat == OutputFormatMDX {
+		return ".mdx"
+	}
+	return ".md"
+}
+
+// writeOutputDir writes the files to the output directory and removes the
+// files listed in the manifest of a previous run that are no longer rendered.
+func (g *generator) writeOutputDir(files map[string][]byte) error {
+	previous, err := readManifest(g.outputDirectory)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(g.outputDirectory, 0755); err != nil {
+		return fmt.Errorf("could not create directory %q: %v", g.outputDirectory, err)
+	}
+
+	for _, name := range sortedFileNames(files) {
+		fileName := filepath.Join(g.outputDirectory, name)
+		if err := os.WriteFile(fileName, files[name], 0600); err != nil {
+			return fmt.Errorf("could not write file %q: %v", fileName, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(g.outputDirectory, manifestFileName), files[manifestFileName], 0600); err != nil {
+		return fmt.Errorf("could not write file %q: %v", filepath.Join(g.outputDirectory, manifestFileName), err)
+	}
+
+	for _, name := range staleFileNames(previous, files) {
+		fileName := filepath.Join(g.outputDirectory, name)
+		if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not remove stale file %q: %v", fileName, err)
+		}
+		klog.Infof("Removed stale file %q", fileName)
+	}
+	klog.Infof("Rendered output written to %q", g.outputDirectory)
+
+	return nil
+}
+
+// checkOutputDir compares the rendered files with the files in the output
+// directory. A StaleOutputError is returned when any file differs, or when a
+// stale file from a previous run would be removed.
+func (g *generator) checkOutputDir(files map[string][]byte) error {
+	previous, err := readManifest(g.outputDirectory)
+	if err != nil {
+		return err
+	}
+
+	var diffs strings.Builder
+	names := append(sortedFileNames(files), manifestFileName)
+	for _, name := range names {
+		fileName := filepath.Join(g.outputDirectory, name)
+		existing, err := os.ReadFile(fileName)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not read file %q: %v", fileName, err)
+		}
+		if !bytes.Equal(existing, files[name]) {
+			diffs.WriteString(unifiedDiff(fileName, fileName+" (generated)", string(existing), string(files[name])))
+		}
+	}
+	for _, name := range staleFileNames(previous, files) {
+		fileName := filepath.Join(g.outputDirectory, name)
+		existing, err := os.ReadFile(fileName)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("could not read file %q: %v", fileName, err)
+		}
+		diffs.WriteString(unifiedDiff(fileName, fileName+" (removed)", string(existing), ""))
+	}
+
+	if diffs.Len() == 0 {
+		klog.Infof("Rendered output matches %q", g.outputDirectory)
+		return nil
+	}
+
+	return &StaleOutputError{
+		FileName: g.outputDirectory,
+		Diff:     diffs.String(),
+	}
+}
+
+// readManifest reads the names of the files written to the directory by a
+// previous run. Names that do not refer to a file directly within the
+// directory are ignored, so that a modified manifest cannot remove other files.
+func readManifest(dir string) ([]string, error) {
+	fileName := filepath.Join(dir, manifestFileName)
+	content, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read manifest %q: %v", fileName, err)
+	}
+
+	var names []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || name == "." || name == ".." || name != filepath.Base(name) || name == manifestFileName {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// staleFileNames returns the names from the previous manifest that are no
+// longer rendered.
+func staleFileNames(previous []string, files map[string][]byte) []string {
+	var out []string
+	for _, name := range previous {
+		if _, ok := files[name]; !ok {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// sortedFileNames returns the names of the rendered files, without the manifest.
+func sortedFileNames(files map[string][]byte) []string {
+	var names []string
+	for name := range files {
+		if name != manifestFileName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"fmt"
+
+	gengo "k8s.io/gengo/v2"
+	"k8s.io/gengo/v2/types"
+)
+
+const (
+	// StabilityAlpha marks types and members that may change or be removed
+	// without notice.
+	StabilityAlpha = "alpha"
+	// StabilityBeta marks types and members that are well tested but may
+	// still change.
+	StabilityBeta = "beta"
+	// StabilityStable marks types and members that will not change
+	// incompatibly. Types and members without a marker are stable.
+	StabilityStable = "stable"
+
+	stabilityMarker = "reference-gen:stability"
+)
+
+// stabilityLevels orders the stability levels from least to most stable.
+var stabilityLevels = map[string]int{
+	StabilityAlpha:  0,
+	StabilityBeta:   1,
+	StabilityStable: 2,
+}
+
+// stabilityOf returns the stability set by the +reference-gen:stability marker
+// in the comments. Comments without a marker are stable.
+func stabilityOf(commentLines []string) string {
+	tags := gengo.ExtractCommentTags("+", commentLines)
+	if values, ok := tags[stabilityMarker]; ok {
+		// There should only be one entry
+		return values[0]
+	}
+	return StabilityStable
+}
+
+// unstable returns the stability of the comments when it is less than stable,
+// so that templates only badge alpha and beta types and members.
+func unstable(commentLines []string) string {
+	if s := stabilityOf(commentLines); s != StabilityStable {
+		return s
+	}
+	return ""
+}
+
+// checkStability checks that the stability markers of the types and their
+// members name a known stability level.
+func checkStability(allTypes map[string]*types.Type) error {
+	for _, t := range allTypes {
+		if _, ok := stabilityLevels[stabilityOf(t.CommentLines)]; !ok {
+			return fmt.Errorf("invalid stability %q on %s, expected one of %q, %q, %q", stabilityOf(t.CommentLines), t.Name, StabilityAlpha, StabilityBeta, StabilityStable)
+		}
+		for _, m := range t.Members {
+			if _, ok := stabilityLevels[stabilityOf(m.CommentLines)]; !ok {
+				return fmt.Errorf("invalid stability %q on %s.%s, expected one of %q, %q, %q", stabilityOf(m.CommentLines), t.Name, m.Name, StabilityAlpha, StabilityBeta, StabilityStable)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	gengo "k8s.io/gengo/v2"
+	"k8s.io/gengo/v2/types"
+)
+
+// fieldTagMarker names the struct tag that a field is marshalled with,
+// overriding the tag priority.
+const fieldTagMarker = "reference-gen:field-tag"
+
+// defaultTagPriority is the order in which the struct tags of fields are
+// used when no tag priority is given.
+var defaultTagPriority = []string{"json", "yaml"}
+
+// memberTag is the struct tag that a member is marshalled with, the first
+// tag of the tag priority that the member has.
+type memberTag struct {
+	// key is the key of the tag, such as json or yaml. It is empty for
+	// untagged members that are not structs, see untaggedKey.
+	key       string
+	name      string
+	ignored   bool
+	omitEmpty bool
+	// inline is set by the inline or squash options, which embed the
+	// members of the field for the tags that support them.
+	inline bool
+}
+
+// parseMemberTag parses the struct tag that the member is marshalled with,
+// the first tag of the priority that the member has, unless a
+// +reference-gen:field-tag marker names another tag.
+// Members without any of the tags are marshalled with the first tag.
+func parseMemberTag(m types.Member, priority []string) memberTag {
+	keys := priority
+	if key := fieldTagKey(m.CommentLines); key != "" {
+		keys = []string{key}
+	}
+
+	for _, key := range keys {
+		value, ok := reflect.StructTag(m.Tags).Lookup(key)
+		if !ok {
+			continue
+		}
+		// A name of "-" with no options leaves the member out, "-," names
+		// the member "-".
+		tag := memberTag{key: key, ignored: value == "-"}
+		options := strings.Split(value, ",")
+		tag.name = options[0]
+		for _, option := range options[1:] {
+			switch option {
+			case "omitempty":
+				tag.omitEmpty = true
+			case "inline", "squash":
+				tag.inline = true
+			}
+		}
+		return tag
+	}
+	return memberTag{key: untaggedKey(m, keys)}
+}
+
+// untaggedKey returns the key of the tag that a member without any of the
+// tags is marshalled with. Structs are marshalled in the same format as
+// their own members, so the key is the first of the keys that their members
+// are tagged with. Other members are marshalled in the same format as the
+// struct declaring them, so their key is left empty, see siblingTagKey.
+func untaggedKey(m types.Member, keys []string) string {
+	t := tryDereference(m.Type)
+	if t.Kind != types.Struct {
+		return ""
+	}
+	if key := structTagKey(t, keys); key != "" {
+		return key
+	}
+	return keys[0]
+}
+
+// siblingTagKey returns the key of the tag that an untagged member of the
+// owner is marshalled with, from the tags of the other members of the owner.
+// The key falls back to the first of the priority when the owner is unknown
+// or none of its members are tagged.
+func siblingTagKey(owner *types.Type, m types.Member, priority []string) string {
+	if key := fieldTagKey(m.CommentLines); key != "" {
+		return key
+	}
+	if owner != nil {
+		if key := structTagKey(owner, priority); key != "" {
+			return key
+		}
+	}
+	return priority[0]
+}
+
+// structTagKey returns the first of the keys that the members of the struct
+// are tagged with, or an empty string when none of them are.
+func structTagKey(t *types.Type, keys []string) string {
+	for _, key := range keys {
+		for _, member := range t.Members {
+			if _, ok := reflect.StructTag(member.Tags).Lookup(key); ok {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// untaggedName returns the name of a member whose tag does not name it.
+// yaml lowercases the name of the field, while the other formats use it as
+// it is.
+func untaggedName(m types.Member, key string) string {
+	if key == "yaml" {
+		return strings.ToLower(m.Name)
+	}
+	return m.Name
+}
+
+// fieldTagKey returns the key of the struct tag set by the
+// +reference-gen:field-tag comment tag, if present.
+func fieldTagKey(commentLines []string) string {
+	tags := gengo.ExtractCommentTags("+", commentLines)
+	if values, ok := tags[fieldTagMarker]; ok {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// checkTagPriority checks the keys of the struct tags in the tag priority.
+func checkTagPriority(priority []string) error {
+	for _, key := range priority {
+		if key == "" || strings.ContainsAny(key, " \t\n\":,") {
+			return fmt.Errorf("invalid tag priority %q, expected a list of struct tag keys such as json,yaml", strings.Join(priority, ","))
+		}
+	}
+	return nil
+}
+
+// tagMismatches lists the visible members whose struct tags of the priority
+// name them differently, as the same configuration then has different keys
+// in each format. Tags without a name name the member after the field, and
+// tags that leave the member out are not compared.
+func tagMismatches(allTypes map[string]*types.Type, priority []string) []string {
+	typeList := make([]*types.Type, 0, len(allTypes))
+	for _, t := range allTypes {
+		typeList = append(typeList, t)
+	}
+
+	var out []string
+	for _, t := range sortTypes(typeList) {
+		for _, m := range t.Members {
+			if hideMember(m, priority) {
+				continue
+			}
+			var names []string
+			first, mismatch := "", false
+			for _, key := range priority {
+				value, ok := reflect.StructTag(m.Tags).Lookup(key)
+				if !ok || value == "-" {
+					// Fields left out of a format have no name to compare.
+					continue
+				}
+				name := strings.Split(value, ",")[0]
+				if name == "" {
+					name = untaggedName(m, key)
+				}
+				if len(names) == 0 {
+					first = name
+				} else if name != first {
+					mismatch = true
+				}
+				names = append(names, fmt.Sprintf("%s %q", key, name))
+			}
+			if mismatch {
+				out = append(out, fmt.Sprintf("field %s.%s has different names in its struct tags: %s", t.Name, m.Name, strings.Join(names, ", ")))
+			}
+		}
+	}
+	return out
+}
+
+// tagFunc constructs a tag function for the template, returning the value
+// of the struct tag of the member with the key given.
+func tagFunc() func(m types.Member, key string) string {
+	return func(m types.Member, key string) string {
+		return reflect.StructTag(m.Tags).Get(key)
+	}
+}
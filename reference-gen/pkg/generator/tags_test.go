@@ -0,0 +1,90 @@
+package generator
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/gengo/v2/types"
+)
+
+var _ = Describe("Struct tags", func() {
+	var allTypes map[string]*types.Type
+
+	BeforeEach(func() {
+		options := &types.Type{
+			Name: types.Name{Package: "github.com/example/project", Name: "Options"},
+			Kind: types.Struct,
+			Members: []types.Member{
+				{Name: "ProxyPrefix", Type: types.String, Tags: `mapstructure:"proxy_prefix" json:"proxyPrefix,omitempty"`},
+				{Name: "Upstreams", Type: types.String, Tags: `mapstructure:"upstreams" json:"upstreams"`},
+				{Name: "PingPath", Type: types.String, Tags: `mapstructure:"" json:"PingPath"`},
+				{Name: "ClientSecret", Type: types.String, Tags: `mapstructure:"client_secret" json:"-"`},
+				{Name: "Hidden", Type: types.String, Tags: `mapstructure:"hidden" json:"shown"`, CommentLines: []string{"+reference-gen:hidden"}},
+			},
+		}
+		allTypes = map[string]*types.Type{options.Name.String(): options}
+	})
+
+	It("should list the fields that are named differently by the tags of the priority", func() {
+		Expect(tagMismatches(allTypes, []string{"mapstructure", "json"})).To(ConsistOf(
+			`field github.com/example/project.Options.ProxyPrefix has different names in its struct tags: mapstructure "proxy_prefix", json "proxyPrefix"`,
+		))
+	})
+
+	It("should only compare the tags of the priority", func() {
+		Expect(tagMismatches(allTypes, []string{"json", "yaml"})).To(BeEmpty())
+	})
+
+	It("should name each field by the first tag of the priority that it has", func() {
+		options := allTypes["github.com/example/project.Options"]
+		members := options.Members
+		for _, m := range members {
+			Expect(parseMemberTag(m, []string{"toml", "json", "mapstructure"}).key).To(Equal("json"), m.Name)
+		}
+		Expect(fieldName(members[0], options, []string{"toml", "json", "mapstructure"})).To(Equal("proxyPrefix"))
+		Expect(fieldName(members[0], options, []string{"mapstructure", "json"})).To(Equal("proxy_prefix"))
+		Expect(fieldName(members[0], options, []string{"toml"})).To(Equal("ProxyPrefix"))
+	})
+
+	It("should name fields marked with a field tag by that tag", func() {
+		options := allTypes["github.com/example/project.Options"]
+		members := options.Members
+		members[1].CommentLines = []string{"+reference-gen:field-tag=mapstructure"}
+		Expect(parseMemberTag(members[1], []string{"json"}).key).To(Equal("mapstructure"))
+		Expect(fieldName(members[1], options, []string{"json"})).To(Equal("upstreams"))
+		Expect(hideMember(members[3], []string{"json"})).To(BeTrue())
+		Expect(hideMember(members[3], []string{"mapstructure", "json"})).To(BeFalse())
+	})
+	It("should apply the inline rule of the tag that anonymous structs are marshalled with", func() {
+		yamlStruct := &types.Type{
+			Name:    types.Name{Package: "github.com/example/project", Name: "Embedded"},
+			Kind:    types.Struct,
+			Members: []types.Member{{Name: "Value", Tags: `yaml:"value"`}},
+		}
+		untagged := types.Member{Name: "Embedded", Embedded: true, Type: yamlStruct}
+		Expect(parseMemberTag(untagged, []string{"json", "yaml"}).key).To(Equal("yaml"))
+		Expect(fieldEmbedded(untagged, []string{"json", "yaml"})).To(BeFalse())
+		Expect(fieldName(untagged, nil, []string{"json", "yaml"})).To(Equal("embedded"))
+
+		inlined := types.Member{Name: "Embedded", Embedded: true, Type: yamlStruct, Tags: `yaml:",inline"`}
+		Expect(fieldEmbedded(inlined, []string{"json", "yaml"})).To(BeTrue())
+
+		yamlStruct.Members[0].Tags = `json:"value"`
+		Expect(fieldEmbedded(untagged, []string{"json", "yaml"})).To(BeTrue())
+	})
+
+	It("should name untagged fields by the tag that the other fields of their struct have", func() {
+		owner := &types.Type{
+			Name: types.Name{Package: "github.com/example/project", Name: "Settings"},
+			Kind: types.Struct,
+			Members: []types.Member{
+				{Name: "Name", Type: types.String, Tags: `yaml:"name"`},
+				{Name: "NonTagged", Type: types.Bool},
+			},
+		}
+		Expect(fieldName(owner.Members[1], owner, []string{"json", "yaml"})).To(Equal("nontagged"))
+
+		owner.Members[0].Tags = `json:"name"`
+		Expect(fieldName(owner.Members[1], owner, []string{"json", "yaml"})).To(Equal("NonTagged"))
+		Expect(fieldName(owner.Members[1], nil, []string{"yaml", "json"})).To(Equal("nontagged"))
+	})
+})
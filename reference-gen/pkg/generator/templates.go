@@ -1,35 +1,134 @@
 package generator
 
 import (
+	"bytes"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"text/template"
+
+	"k8s.io/gengo/v2/types"
 )
 
 var defaultTemplates = []string{
+	frontMatterTemplate,
 	packageTemplate,
+	tocTemplate,
+	packageSectionTemplate,
+	indexTemplate,
+	indexTableTemplate,
+	keyIndexTemplate,
 	typeTemplate,
+	typeNoticesTemplate,
 	memberTemplate,
+	memberPathsTemplate,
 	membersTemplate,
 	memberWithEmbedTemplate,
+	deprecationTemplate,
+	stabilityTemplate,
+	deprecatedOptionsTemplate,
 }
 
+const frontMatterTemplate = `
+{{ define "front_matter" -}}
+{{ with . -}}
+---
+{{ range . }}{{ .Key }}: {{ yamlValue .Value }}
+{{ end -}}
+---
+
+{{ end -}}
+{{ end }}
+`
+
 const packageTemplate = `
 {{- define "package" -}}
-    {{- range (visibleTypes (sortedTypes .types)) -}}
-        {{ template "type" .  }}
+    {{- if showTableOfContents -}}
+        {{ template "toc" . }}
+    {{- end -}}
+    {{- if gt (len .packages) 1 -}}
+        {{- range .packages -}}
+            {{ template "package_section" . }}
+        {{- end -}}
+    {{- else -}}
+        {{- range (visibleTypes (sortedTypes .types)) -}}
+            {{ template "type" .  }}
+        {{- end -}}
+    {{- end -}}
+    {{- template "deprecated_options" . -}}
+{{- end -}}
+`
+
+const tocTemplate = `
+{{ define "toc" }}
+## Table of contents
+{{ range tableOfContents .types }}
+**[{{ escapeText (typeName .Root) }}]({{ linkForType .Root }})**{{ with synopsis .Root.CommentLines }}: {{ . }}{{ end }}
+{{ with .Types }}
+| Type | Description |
+| ---- | ----------- |
+{{- range . }}
+| [{{ escapeCell (typeName .) }}]({{ linkForType . }}) | {{ synopsis .CommentLines }} |
+{{- end }}
+{{ end -}}
+{{ end -}}
+{{ end }}
+`
+
+const packageSectionTemplate = `
+{{ define "package_section" }}
+## Package {{ backtick .Path }}
+{{ range (visibleTypes (sortedTypes .Types)) -}}
+    {{ template "type" .  }}
+{{- end -}}
+{{ end }}
+`
+
+const indexTemplate = `
+{{- define "index" -}}
+    {{- if gt (len .packages) 1 -}}
+        {{- range .packages }}
+## Package {{ backtick .Path }}
+{{ template "index_table" .Types }}
+        {{- end -}}
+    {{- else -}}
+        {{ template "index_table" .types }}
     {{- end -}}
+    {{- template "deprecated_options" . -}}
 {{- end -}}
 `
 
+const indexTableTemplate = `
+{{ define "index_table" }}
+| Type | Description |
+| ---- | ----------- |
+{{- range (visibleTypes (sortedTypes .)) }}
+| [{{ escapeCell (typeName .) }}]({{ linkForType . }}) | {{ synopsis .CommentLines }} |
+{{- end }}
+{{ end }}
+`
+
+const keyIndexTemplate = `
+{{ define "key_index" }}
+| Key | Type | Required | Description |
+| --- | ---- | -------- | ----------- |
+{{- range configKeys .types }}
+| [{{ escapeCell (backtick .Path) }}]({{ keyLink .Member }}) | _{{ escapeCell (typeDisplayName .Member.Type) }}_ | {{ if isOptionalMember .Member }}Optional{{ else }}Required{{ end }} | {{ synopsis .Member.CommentLines }} |
+{{- end }}
+{{ end }}
+`
+
 const typeTemplate = `
 {{ define "type" }}
-### {{ .Name.Name }}
+{{- with headingAnchor . }}
+<a id="{{ . }}"></a>
+{{- end }}
+### {{ escapeText (typeName .) }}
 {{- if or (eq .Kind "Alias") (aliasDisplayName .) }}
 {{ if linkForType .Underlying }}
-#### ([{{ aliasDisplayName . }}]({{ linkForType .Underlying}}) alias)
+#### ([{{ escapeText (aliasDisplayName .) }}]({{ linkForType .Underlying}}) alias)
 {{- else -}}
-#### ({{ backtick (aliasDisplayName .)}} alias)
+#### ({{ escapeText (backtick (aliasDisplayName .)) }} alias)
 {{- end -}}
 {{ end }}
 {{ with (typeReferences .) }}
@@ -37,35 +136,67 @@ const typeTemplate = `
     {{- range . -}}
         {{- if $prev -}}, {{ end -}}
         {{- $prev = . -}}
-        [{{ typeDisplayName . }}]({{ linkForType . }})
+        [{{ escapeText (typeDisplayName .) }}]({{ linkForType . }})
     {{- end -}}
   )
 {{ end }}
+{{ template "type_notices" . -}}
 {{ renderCommentsLF .CommentLines }}
+{{ with enumValues . }}
+| Value | Description |
+| ----- | ----------- |
+{{- range . }}
+| {{ escapeCell (backtick (enumDisplayValue .)) }} | {{ renderCommentsBR .CommentLines }} |
+{{- end }}
+{{ end -}}
 {{ if visibleMembers .Members }}
-| Field | Type | Description |
-| ----- | ---- | ----------- |
+| Field | Type | Description |{{ if showDefaultColumn }} Default |{{ end }}{{ if showConstraintsColumn }} Constraints |{{ end }}
+| ----- | ---- | ----------- |{{ if showDefaultColumn }} ------- |{{ end }}{{ if showConstraintsColumn }} ----------- |{{ end }}
 {{- template "members_with_embed" . }}
 {{ end -}}
 {{ end }}
 `
 
+const typeNoticesTemplate = `
+{{ define "type_notices" }}
+{{- with deprecation .CommentLines -}}
+> {{ template "deprecation" . }}
+
+{{ end -}}
+{{ with unstable .CommentLines -}}
+{{ template "stability" . }}
+
+{{ end -}}
+{{ end }}
+`
+
 const memberTemplate = `
 {{ define "member" }}
   {{- if not (hideMember .) }}
-| {{ backtick (fieldName .) }} | _{{- if linkForType .Type -}}
-    [{{ typeDisplayName .Type }}]({{ linkForType .Type}})
-  {{- else -}}
-    {{ typeDisplayName .Type }}
-  {{- end -}}_ | {{ if fieldEmbedded . -}}
-    (Members of {{ backtick (fieldName .) }} are embedded into this type.)
-  {{ end -}}
-  {{- if isOptionalMember . }} _(Optional)_ {{ end -}}
-  {{- renderCommentsBR .CommentLines }} |
+| {{ with memberAnchor . }}<a id="{{ . }}"></a>{{ end }}{{ if deprecation .CommentLines }}~~{{ escapeCell (backtick (fieldName .)) }}~~{{ else }}{{ escapeCell (backtick (fieldName .)) }}{{ end }} | _{{ linkedTypeDisplayName .Type }}_{{ if recursiveMember . }} (recursive){{ end }} | {{ $sep := "" -}}
+  {{ with deprecation .CommentLines }}{{ template "deprecation" . }}{{ $sep = "<br/>" }}{{ end -}}
+  {{ with unstable .CommentLines }}{{ $sep }}{{ template "stability" . }}{{ $sep = " " }}{{ end -}}
+  {{ if fieldEmbedded . }}{{ $sep }}(Members of {{ escapeCell (backtick (fieldName .)) }} are embedded into this type.){{ $sep = " " }}{{ end -}}
+  {{ if isOptionalMember . }}{{ $sep }}_(Optional)_{{ $sep = " " }}{{ end -}}
+  {{ with renderCommentsBR .CommentLines }}{{ $sep }}{{ . }}{{ $sep = "<br/>" }}{{ end -}}
+  {{ with commonTypeDescription .Type }}{{ if $sep }}<br/>{{ end }}{{ . }}{{ $sep = "<br/>" }}{{ end -}}
+  {{ with enumValues .Type }}{{ if $sep }}<br/>{{ end }}Allowed values: {{ range $i, $v := . }}{{ if $i }}, {{ end }}{{ escapeCell (backtick (enumDisplayValue $v)) }}{{ end }}.{{ $sep = "<br/>" }}{{ end -}}
+  {{ $paths := includeMember "member_paths" . }}{{ if $sep }}{{ $paths }}{{ else }}{{ trimPrefix "<br/>" $paths }}{{ end }} |
+  {{- if showDefaultColumn }}{{ with defaultValue . }} {{ escapeCell (backtick .) }}{{ else }}{{ range $i, $d := defaultsByPath . }}{{ if $i }}<br/>{{ else }} {{ end }}{{ escapeCell (backtick $d.Value) }} at {{ escapeCell (backtick $d.Path) }}{{ end }}{{ end }} |{{ end }}
+  {{- if showConstraintsColumn }}{{ range $i, $c := constraints . }}{{ if $i }}<br/>{{ else }} {{ end }}{{ $c.Name }}: {{ escapeCell (backtick $c.Value) }}{{ end }} |{{ end }}
   {{- end -}}
 {{- end }}
 `
 
+const memberPathsTemplate = `
+{{ define "member_paths" }}
+  {{- $paths := memberPaths . -}}
+  {{- if or (gt (len $paths) 1) (and $paths (ne (index $paths 0) (fieldName .))) -}}
+    <br/>{{ if gt (len $paths) 1 }}Paths{{ else }}Path{{ end }}: {{ range $i, $p := $paths }}{{ if $i }}, {{ end }}{{ escapeCell (backtick $p) }}{{ end }}
+  {{- end -}}
+{{ end }}
+`
+
 const membersTemplate = `
 {{ define "members" }}
 {{- range .Members -}}
@@ -78,7 +209,7 @@ const memberWithEmbedTemplate = `
 {{ define "members_with_embed" }}
 {{- range .Members -}}
 {{- if fieldEmbedded . -}}
-{{- template "members" (dereference .Type) -}}
+{{- template "members" (embeddedMembers .) -}}
 {{- else -}}
 {{- template "member" . -}}
 {{- end -}}
@@ -86,24 +217,83 @@ const memberWithEmbedTemplate = `
 {{ end }}
 `
 
-// loadTemplatesInto loads templates from the directory given, or the default
-// templates, into the template object.
-func loadTemplatesInto(t *template.Template, templateDir string) (*template.Template, error) {
-	// No template directory given, use default templates
-	if templateDir == "" {
-		return loadDefaultTemplatesInto(t)
+const deprecationTemplate = `
+{{ define "deprecation" -}}
+{{ with .Message }}**Deprecated:** {{ escapeCell . }}{{ else }}**Deprecated.**{{ end }}
+{{- with .Replacement }} Use {{ replacementLink . }} instead.{{ end }}
+{{- end }}
+`
+
+const stabilityTemplate = `
+{{ define "stability" -}}
+{{ if eq . "alpha" }}**Alpha**{{ else if eq . "beta" }}**Beta**{{ end }}
+{{- end }}
+`
+
+const deprecatedOptionsTemplate = `
+{{ define "deprecated_options" }}
+{{- with deprecatedOptions .types }}
+## Deprecated options
+
+| Option | Deprecation |
+| ------ | ----------- |
+{{- range . }}
+| [{{ escapeCell (backtick .Name) }}]({{ with .Member }}{{ memberLink . }}{{ else }}{{ linkForType .Type }}{{ end }}) | {{ template "deprecation" .Deprecation }} |
+{{- end }}
+{{ end -}}
+{{ end }}
+`
+
+// includeMember executes the named template with the member and returns its
+// output, so that a template can adjust the output of another before writing
+// it, such as the separator that starts the paths of a member.
+// The member is taken by its address, as the functions of the templates
+// know members by their address.
+func includeMember(t *template.Template, name string, m *types.Member) (string, error) {
+	var buff bytes.Buffer
+	if err := t.ExecuteTemplate(&buff, name, m); err != nil {
+		return "", err
 	}
+	return buff.String(), nil
+}
+
+// trimPrefix removes the prefix from the string. The prefix is the first
+// argument so that the string can be piped into it.
+func trimPrefix(prefix, s string) string {
+	return strings.TrimPrefix(s, prefix)
+}
 
+// loadTemplatesInto loads the default templates into the template object,
+// followed by the templates of the profile and the templates from the
+// directory given, if any. Later templates override earlier templates of the
+// same name, so that a template directory only needs to define the templates
+// it changes.
+func loadTemplatesInto(t *template.Template, p *profile, templateDir string) (*template.Template, error) {
+	t, err := parseTemplatesInto(t, defaultTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("error loading default template: %v", err)
+	}
+
+	if p != nil {
+		t, err = parseTemplatesInto(t, p.templates)
+		if err != nil {
+			return nil, fmt.Errorf("error loading profile template: %v", err)
+		}
+	}
+
+	if templateDir == "" {
+		return t, nil
+	}
 	return t.ParseGlob(filepath.Join(templateDir, "*.tpl"))
 }
 
-// loadDefaultTemplatesInto loads the defaultTemplates into the template object.
-func loadDefaultTemplatesInto(t *template.Template) (*template.Template, error) {
+// parseTemplatesInto parses the templates into the template object.
+func parseTemplatesInto(t *template.Template, templates []string) (*template.Template, error) {
 	var err error
-	for _, tmpl := range defaultTemplates {
+	for _, tmpl := range templates {
 		t, err = t.Parse(tmpl)
 		if err != nil {
-			return nil, fmt.Errorf("error loading default template: %v", err)
+			return nil, err
 		}
 	}
 	return t, nil
@@ -0,0 +1,66 @@
+package audience
+
+// Options is the root of the configuration.
+type Options struct {
+	// Upstream configures the upstream server.
+	Upstream Upstream `json:"upstream"`
+
+	// TestHook is called on every request, for use in tests.
+	// +reference-gen:hidden
+	// +optional
+	TestHook func() `json:"-"`
+
+	// Debug configures debugging of the proxy.
+	// +reference-gen:audience=internal
+	// +optional
+	Debug *Debug `json:"debug,omitempty"`
+
+	// Shim configures the legacy shim.
+	// +optional
+	Shim *LegacyShim `json:"shim,omitempty"`
+
+	// Shims configures the legacy shims of each route.
+	// +optional
+	Shims []*LegacyShim `json:"shims,omitempty"`
+
+	// Caches configures the caches of the proxy, by name.
+	// +optional
+	Caches map[string]*Cache `json:"caches,omitempty"`
+}
+
+// Upstream configures the upstream server.
+type Upstream struct {
+	// URL is the address of the upstream server.
+	URL string `json:"url"`
+
+	// DialTimeout is the timeout for connecting to the upstream.
+	// +reference-gen:audience=internal,operators
+	// +optional
+	DialTimeout string `json:"dialTimeout,omitempty"`
+}
+
+// Debug configures debugging of the proxy.
+type Debug struct {
+	// Profiler configures the profiler.
+	Profiler Profiler `json:"profiler"`
+}
+
+// Profiler configures the profiler.
+type Profiler struct {
+	// BindAddress is the address the profiler listens on.
+	BindAddress string `json:"bindAddress"`
+}
+
+// LegacyShim is kept for backwards compatibility and is not documented.
+// +reference-gen:hidden
+type LegacyShim struct {
+	// Enabled enables the shim.
+	Enabled bool `json:"enabled"`
+}
+
+// Cache configures a cache of the proxy.
+// +reference-gen:audience=internal
+type Cache struct {
+	// Size is the maximum number of entries in the cache.
+	Size int `json:"size"`
+}
@@ -0,0 +1,7 @@
+package broken
+
+// BrokenStruct references a type that does not exist.
+type BrokenStruct struct {
+	// Missing has an undefined type.
+	Missing UndefinedType `json:"missing"`
+}
@@ -0,0 +1,9 @@
+package buildtags
+
+// TaggedStruct has members that are only present with the extra build tag.
+type TaggedStruct struct {
+	// Name is always present.
+	Name string `json:"name"`
+
+	ExtraOptions
+}
@@ -0,0 +1,9 @@
+//go:build extra
+
+package buildtags
+
+// ExtraOptions are only included with the extra build tag.
+type ExtraOptions struct {
+	// Extra is only present with the extra build tag.
+	Extra bool `json:"extra"`
+}
@@ -0,0 +1,6 @@
+//go:build !extra
+
+package buildtags
+
+// ExtraOptions are empty without the extra build tag.
+type ExtraOptions struct{}
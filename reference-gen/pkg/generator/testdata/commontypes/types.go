@@ -0,0 +1,20 @@
+package commontypes
+
+import (
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Upstream configures a single upstream.
+type Upstream struct {
+	// URL is the address of the upstream.
+	URL url.URL `json:"url"`
+
+	// PathMatcher selects the requests that are sent to the upstream.
+	// +optional
+	PathMatcher *regexp.Regexp `json:"pathMatcher,omitempty"`
+
+	// FlushInterval is the period between flushing the response buffer.
+	FlushInterval time.Duration `json:"flushInterval"`
+}
@@ -0,0 +1,37 @@
+package defaults
+
+import (
+	"time"
+)
+
+// Server configures the HTTP server.
+type Server struct {
+	// BindAddress is the address the server listens on.
+	// +reference-gen:default=127.0.0.1:4180
+	BindAddress string `json:"bindAddress"`
+
+	// Timeout is how long to wait for a request to complete.
+	Timeout time.Duration `json:"timeout" default:"30s"`
+
+	// Workers is the number of request workers.
+	// The marker takes priority over the struct tag.
+	// +reference-gen:default=4
+	Workers int `json:"workers" default:"1"`
+
+	// EnableHTTP2 enables HTTP/2 support.
+	EnableHTTP2 bool `json:"enableHTTP2" default:"true"`
+
+	// TLS configures serving over TLS.
+	// +optional
+	TLS *TLS `json:"tls,omitempty"`
+}
+
+// TLS configures the certificates used to serve over TLS.
+type TLS struct {
+	// CertFile is the path to the certificate file.
+	CertFile string `json:"certFile"`
+
+	// MinVersion is the minimum TLS version accepted.
+	// +reference-gen:default="TLS1.2"
+	MinVersion string `json:"minVersion"`
+}
@@ -0,0 +1,42 @@
+package defaultsfunc
+
+import (
+	"time"
+)
+
+// DefaultCookieName is the default name of the session cookie.
+const DefaultCookieName = "_oauth2_proxy"
+
+// NewOptions constructs the default options.
+func NewOptions() *Options {
+	opts := Options{
+		ProxyPrefix:    "/oauth2",
+		FlushInterval:  1 * time.Second,
+		SkipAuthRoutes: []string{"GET=^/ping$"},
+		Workers:        4,
+		Cookie: Cookie{
+			Name:         DefaultCookieName,
+			Expire:       168 * time.Hour,
+			Secure:       true,
+			RefreshRatio: 0.5,
+		},
+		Server: &Server{
+			BindAddress: "127.0.0.1:4180",
+			TLS: TLS{
+				MinVersion: "TLS1.2",
+			},
+		},
+		Upstream: Upstream{
+			TLS: TLS{
+				MinVersion: "TLS1.3",
+			},
+		},
+	}
+	return &opts
+}
+
+func newLogging() Logging {
+	return Logging{
+		Level: "info",
+	}
+}
@@ -0,0 +1,78 @@
+package defaultsfunc
+
+import (
+	"time"
+)
+
+// Options is the root of the configuration.
+type Options struct {
+	// ProxyPrefix is the URL path prefix of the proxy endpoints.
+	ProxyPrefix string `json:"proxyPrefix"`
+
+	// FlushInterval is the period between flushing the response buffer.
+	FlushInterval time.Duration `json:"flushInterval"`
+
+	// SkipAuthRoutes are the routes that do not require authentication.
+	SkipAuthRoutes []string `json:"skipAuthRoutes"`
+
+	// Workers is the number of request workers.
+	// The marker takes priority over the defaults function.
+	// +reference-gen:default=8
+	Workers int `json:"workers"`
+
+	// Cookie configures the session cookie.
+	Cookie Cookie `json:"cookie"`
+
+	// Server configures the HTTP server.
+	Server *Server `json:"server"`
+
+	// Upstream configures the connection to the upstream.
+	Upstream Upstream `json:"upstream"`
+
+	// Logging configures the logger.
+	Logging Logging `json:"logging"`
+}
+
+// Cookie configures the session cookie.
+type Cookie struct {
+	// Name is the name of the cookie.
+	Name string `json:"name"`
+
+	// Expire is how long the cookie is valid for.
+	Expire time.Duration `json:"expire"`
+
+	// Secure sets the secure flag of the cookie.
+	Secure bool `json:"secure"`
+
+	// RefreshRatio is the fraction of Expire after which the cookie is refreshed.
+	RefreshRatio float64 `json:"refreshRatio"`
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	// BindAddress is the address the server listens on.
+	BindAddress string `json:"bindAddress"`
+
+	// TLS configures the TLS of the listener.
+	TLS TLS `json:"tls"`
+}
+
+// Upstream configures the connection to the upstream.
+type Upstream struct {
+	// TLS configures the TLS of the upstream connection.
+	TLS TLS `json:"tls"`
+}
+
+// TLS configures a TLS connection.
+// The defaults function sets a different minimum version under each parent.
+type TLS struct {
+	// MinVersion is the minimum TLS version accepted.
+	MinVersion string `json:"minVersion"`
+}
+
+// Logging configures the logger.
+// +reference-gen:defaults-func=newLogging
+type Logging struct {
+	// Level is the minimum level of logs that are written.
+	Level string `json:"level"`
+}
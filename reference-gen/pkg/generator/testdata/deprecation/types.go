@@ -0,0 +1,60 @@
+package deprecation
+
+// Options is the root of the configuration.
+type Options struct {
+	// Cookie configures the session cookie.
+	Cookie Cookie `json:"cookie"`
+
+	// CookieSecret is the secret used to sign the session cookie.
+	//
+	// Deprecated: The secret is now configured on the cookie.
+	// +reference-gen:deprecated=Cookie
+	// +optional
+	CookieSecret string `json:"cookieSecret,omitempty"`
+
+	// Legacy configures the legacy session store.
+	// +optional
+	Legacy *LegacyStore `json:"legacy,omitempty"`
+
+	// SkipProviderButton skips the sign in page.
+	// +reference-gen:deprecated=signInPage.skip
+	// +optional
+	SkipProviderButton bool `json:"skipProviderButton,omitempty"`
+
+	// CookieName is the name of the session cookie.
+	// +reference-gen:deprecated=Cookie.name
+	// +optional
+	CookieName string `json:"cookieName,omitempty"`
+
+	// SessionSecret is the secret used to sign the session.
+	// +reference-gen:deprecated=cookie.secret
+	// +optional
+	SessionSecret string `json:"sessionSecret,omitempty"`
+
+	// ApprovalPrompt is the prompt shown when signing in.
+	// +reference-gen:deprecated=prompt=consent|login
+	// +optional
+	ApprovalPrompt string `json:"approvalPrompt,omitempty"`
+
+	// NotDeprecated mentions that it is not
+	// Deprecated: in the middle of a paragraph.
+	NotDeprecated string `json:"notDeprecated"`
+}
+
+// Cookie configures the session cookie.
+type Cookie struct {
+	// Name is the name of the cookie.
+	Name string `json:"name"`
+
+	// Secret is the secret used to sign the cookie.
+	Secret string `json:"secret"`
+}
+
+// LegacyStore configures the legacy session store.
+//
+// Deprecated: The legacy session store will be removed in the next major
+// release.
+type LegacyStore struct {
+	// Path is the directory sessions are stored in.
+	Path string `json:"path"`
+}
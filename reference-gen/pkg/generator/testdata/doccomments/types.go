@@ -0,0 +1,56 @@
+package doccomments
+
+import (
+	"time"
+)
+
+// Options configures the proxy.
+//
+// # Sessions
+//
+// Sessions are stored in a cookie by default, see [Cookie] for the options
+// of the cookie and [Options.Redis] to store sessions in Redis instead.
+type Options struct {
+	// Cookie configures the session cookie.
+	Cookie Cookie `json:"cookie"`
+
+	// Redis configures storing sessions in Redis.
+	// Sessions are encrypted before they are stored.
+	//
+	// Supported deployments are:
+	//   - standalone
+	//   - sentinel
+	//   - cluster
+	// +optional
+	Redis *Redis `json:"redis,omitempty"`
+
+	// Timeout is parsed with [time.ParseDuration], for example:
+	//
+	//	timeout: 30s
+	Timeout time.Duration `json:"timeout"`
+}
+
+// Cookie configures the session cookie.
+// Its expiry is a [time.Duration], refreshed after [Cookie.Refresh].
+type Cookie struct {
+	// Refresh is the period after which the cookie is refreshed.
+	// See https://example.com/refresh for details.
+	Refresh time.Duration `json:"refresh"`
+}
+
+// Redis configures storing sessions in Redis.
+//
+// Steps to configure Redis:
+//  1. Deploy Redis.
+//  2. Set the connection URL.
+//
+// For example, in a Markdown document:
+//
+//	```yaml
+//	redis:
+//	  connectionURL: redis://localhost:6379
+//	```
+type Redis struct {
+	// ConnectionURL is the URL of the Redis server.
+	ConnectionURL string `json:"connectionURL"`
+}
@@ -0,0 +1,43 @@
+package docusaurus
+
+// Options is the root of the configuration.
+type Options struct {
+	// Session configures the session storage.
+	Session Session `json:"session"`
+
+	// Tracing configures distributed tracing, such as {"sampler": "always"}.
+	// +reference-gen:stability=alpha
+	// +optional
+	Tracing *Tracing `json:"tracing,omitempty"`
+
+	// LegacySession configures the session storage of earlier releases.
+	//
+	// Deprecated: Sessions are configured with the session option.
+	// +reference-gen:deprecated=Session
+	// +optional
+	LegacySession *LegacySession `json:"legacySession,omitempty"`
+}
+
+// Session configures the session storage.
+type Session struct {
+	// Type is the type of storage, one of:
+	//   - cookie
+	//   - redis
+	Type string `json:"type"`
+}
+
+// Tracing configures distributed tracing.
+// +reference-gen:stability=alpha
+type Tracing struct {
+	// Endpoint is the address of the collector.
+	Endpoint string `json:"endpoint"`
+}
+
+// LegacySession configures the session storage of earlier releases.
+//
+// Deprecated: Sessions are configured with the session option.
+// +reference-gen:deprecated=Session
+type LegacySession struct {
+	// Redis enables storing sessions in Redis.
+	Redis bool `json:"redis"`
+}
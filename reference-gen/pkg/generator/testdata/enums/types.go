@@ -0,0 +1,96 @@
+package enums
+
+import "time"
+
+// Provider configures an identity provider.
+type Provider struct {
+	// Type is the type of the provider.
+	Type ProviderType `json:"type"`
+
+	// SameSite is the SameSite mode of the session cookie.
+	// +optional
+	SameSite SameSiteMode `json:"sameSite,omitempty"`
+
+	// LogLevel is the minimum level of logs written for the provider.
+	// +optional
+	LogLevel *LogLevel `json:"logLevel,omitempty"`
+
+	// FallbackTypes are the types of the providers tried when the provider
+	// fails.
+	// +optional
+	FallbackTypes []ProviderType `json:"fallbackTypes,omitempty"`
+
+	// Timeout is the timeout of requests to the provider.
+	// +optional
+	Timeout Timeout `json:"timeout,omitempty"`
+
+	// Scope is the scope requested from the provider.
+	// +optional
+	Scope Scope `json:"scope,omitempty"`
+}
+
+// ProviderType is the type of an identity provider.
+// +reference-gen:enum
+type ProviderType string
+
+const (
+	// OIDCProvider authenticates with any OpenID Connect provider.
+	OIDCProvider ProviderType = "oidc"
+
+	// GitHubProvider authenticates with GitHub.
+	GitHubProvider ProviderType = "github"
+
+	// GoogleProvider authenticates with Google.
+	GoogleProvider ProviderType = "google"
+)
+
+// SameSiteMode is the SameSite mode of a cookie.
+// +reference-gen:enum
+type SameSiteMode string
+
+const (
+	// SameSiteDefault does not set the SameSite attribute.
+	SameSiteDefault SameSiteMode = ""
+	// SameSiteLax sets the SameSite attribute to Lax.
+	SameSiteLax SameSiteMode = "lax"
+	// SameSiteStrict sets the SameSite attribute to Strict.
+	SameSiteStrict SameSiteMode = "strict"
+	// SameSiteNone sets the SameSite attribute to None.
+	SameSiteNone SameSiteMode = "none"
+)
+
+// LogLevel is the level of a log message.
+type LogLevel int
+
+const (
+	// DebugLevel logs everything.
+	DebugLevel LogLevel = iota
+	// InfoLevel logs informational messages and errors.
+	InfoLevel
+	// ErrorLevel only logs errors.
+	ErrorLevel
+)
+
+// maxLevel is unexported, so is not an allowed value.
+const maxLevel LogLevel = 10
+
+// DefaultProviderType is an untyped constant, so is not an allowed value.
+const DefaultProviderType = "oidc"
+
+// Timeout is a duration, written as a string such as "30s".
+// +reference-gen:alias-name=string
+type Timeout time.Duration
+
+// DefaultTimeout is a default, rather than an allowed value of a type with an
+// alias name.
+const DefaultTimeout Timeout = Timeout(30 * time.Second)
+
+// Scope is an OAuth scope.
+type Scope string
+
+// OpenIDScope is a well known scope.
+const OpenIDScope Scope = "openid"
+
+// EmailScope is declared apart from the other scopes, so the scopes are not
+// an enum.
+const EmailScope Scope = "email"
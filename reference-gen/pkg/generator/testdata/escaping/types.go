@@ -0,0 +1,29 @@
+package escaping
+
+// Upstream configures an upstream server. Requests are routed to the first
+// upstream whose path matches, or to the _default_ upstream.
+//
+// Templates use the {{ .Name }} syntax, for example:
+//
+//	header: X-User | {{ .Email }} <{{ .User }}>
+type Upstream struct {
+	// ID identifies the upstream. The ID <nil> or an empty ID is not allowed.
+	// +kubebuilder:validation:Pattern=^(http|https|file)$
+	ID string `json:"id"`
+
+	// Path matches requests as a regular expression, such as `^/(api|static)/`,
+	// or a prefix, such as /static/*.
+	// +reference-gen:default=/a|b
+	Path string `json:"path"`
+
+	// Headers are passed on as map[string]string{"X-Forwarded-For": "{{ .IP }}"}.
+	// A stray ` backtick, a <script> tag and a snake_case_name are shown as written.
+	//
+	//	X-Auth: {{ .Token }} | <none>
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Flush sets the flush interval:
+	//   - a | separated list
+	//   - a {value} in braces
+	Flush string `json:"flush_interval"`
+}
@@ -0,0 +1,72 @@
+package expressions
+
+import "net/url"
+
+// Config uses composite types for its fields.
+type Config struct {
+	// UpstreamGroups are groups of upstreams, keyed by their name.
+	UpstreamGroups []map[string]*Upstream `json:"upstreamGroups,omitempty"`
+
+	// RulesByHost are the rules checked for each host.
+	RulesByHost map[string][]Rule `json:"rulesByHost,omitempty"`
+
+	// Routes are the routes to check, when set.
+	Routes *[]Rule `json:"routes,omitempty"`
+
+	// Secret is a fixed size secret.
+	Secret [4]byte `json:"secret,omitempty"`
+
+	// Fingerprint is the fingerprint of the certificate.
+	Fingerprint Fingerprint `json:"fingerprint,omitempty"`
+
+	// Weights are keyed by a documented key type.
+	Weights map[Key]Weight `json:"weights,omitempty"`
+
+	// Nested nests maps, slices and arrays.
+	Nested map[Key][][2]*Weight `json:"nested,omitempty"`
+
+	// Endpoints are keyed by an external type.
+	Endpoints map[url.URL]Upstream `json:"endpoints,omitempty"`
+
+	// Events receives the rules as they change.
+	Events chan Rule `json:"events,omitempty"`
+
+	// Updates only receives the rules as they change.
+	Updates <-chan Rule `json:"updates,omitempty"`
+
+	// Acks only sends the acknowledgements of the rules.
+	Acks chan<- Rule `json:"acks,omitempty"`
+
+	// Streams receives the channels of each stream of rules.
+	Streams chan (<-chan Rule) `json:"streams,omitempty"`
+
+	// Check is called to check a rule.
+	Check func(Rule, ...string) (bool, error) `json:"check,omitempty"`
+
+	// OnChange is called when the configuration changes.
+	OnChange func() `json:"onChange,omitempty"`
+}
+
+// Upstream is a server requests are proxied to.
+type Upstream struct {
+	// URI is the address of the upstream.
+	URI string `json:"uri"`
+}
+
+// Rule matches requests.
+type Rule struct {
+	// Pattern matches the path of the request.
+	Pattern string `json:"pattern"`
+}
+
+// Key identifies a weight.
+type Key string
+
+// Weight is the share of requests.
+type Weight struct {
+	// Value is the weight.
+	Value int `json:"value"`
+}
+
+// Fingerprint is a SHA-256 fingerprint.
+type Fingerprint [32]byte
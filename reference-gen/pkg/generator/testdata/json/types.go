@@ -63,9 +63,13 @@ type MyTestStruct struct {
 
 // SomeSubStruct is a struct to go within another struct.
 type SomeSubStruct struct {
-	// NonTaggedField doesn't have a tag, so the name will be capitalised.
+	// NonTaggedField doesn't have a tag, so it is named as the tags of the
+	// other fields of the struct would name it.
 	NonTaggedField bool
 
+	// TaggedField has a tag, which sets the format of the struct.
+	TaggedField bool `json:"taggedField"`
+
 	// privateStruct should not be included in the docs.
 	privateStruct PrivateMembers
 }
@@ -96,3 +100,57 @@ type MyDurationString time.Duration
 
 // AliasedExternalMap is an alias type for a map type outside of the package.
 type AliasedExternalMap template.FuncMap
+
+// FieldSemantics has fields that are marshalled differently to their names.
+type FieldSemantics struct {
+	// Ignored is left out when marshalled.
+	Ignored string `json:"-"`
+
+	// Dash is named "-" by the comma following the name.
+	Dash string `json:"-,"`
+
+	// Optional is left out when it is empty.
+	Optional string `json:"optional,omitempty"`
+
+	// Inline is not inlined, as encoding/json has no inline option.
+	Inline InlineStruct `json:",inline"`
+
+	// NamedEmbedded has a name, so its members are not embedded.
+	NamedEmbedded `json:"named"`
+
+	// PointerEmbedded is embedded by a pointer.
+	*PointerEmbedded
+
+	// unexportedEmbedded is unexported, but its members are still embedded.
+	unexportedEmbedded
+
+	// Anonymous is a struct without a name.
+	Anonymous struct {
+		// Enabled is a member of the anonymous struct.
+		Enabled bool `json:"enabled"`
+	} `json:"anonymous,omitempty"`
+}
+
+// InlineStruct is a struct that may be inlined.
+type InlineStruct struct {
+	// InlineValue is a member of the inlined struct.
+	InlineValue string `json:"inlineValue"`
+}
+
+// NamedEmbedded is embedded with a name.
+type NamedEmbedded struct {
+	// NamedValue is a member of the named struct.
+	NamedValue string `json:"namedValue"`
+}
+
+// PointerEmbedded is embedded by a pointer.
+type PointerEmbedded struct {
+	// PointerValue is a member of the struct embedded by a pointer.
+	PointerValue string `json:"pointerValue,omitempty"`
+}
+
+// unexportedEmbedded is an unexported struct that is embedded.
+type unexportedEmbedded struct {
+	// UnexportedValue is a member of the unexported struct.
+	UnexportedValue string `json:"unexportedValue"`
+}
@@ -0,0 +1,17 @@
+package server
+
+import (
+	"github.com/oauth2-proxy/tools/reference-gen/pkg/generator/testdata/multi/upstream"
+)
+
+// Options configures the server.
+type Options struct {
+	// BindAddress is the address the server listens on.
+	BindAddress string `json:"bindAddress"`
+
+	// Upstreams are the upstreams the server proxies to.
+	Upstreams []upstream.Upstream `json:"upstreams"`
+
+	// UpstreamOptions configures how the upstreams are proxied.
+	UpstreamOptions upstream.Options `json:"upstreamOptions"`
+}
@@ -0,0 +1,17 @@
+package upstream
+
+import (
+	"time"
+)
+
+// Upstream is a single upstream to proxy to.
+type Upstream struct {
+	// ID identifies the upstream.
+	ID string `json:"id"`
+}
+
+// Options configures how upstreams are proxied.
+type Options struct {
+	// FlushInterval is the period between flushing the response buffer.
+	FlushInterval time.Duration `json:"flushInterval"`
+}
@@ -0,0 +1,50 @@
+package paths
+
+// AlphaOptions is the root of the configuration.
+type AlphaOptions struct {
+	// UpstreamConfig configures the upstream servers.
+	UpstreamConfig UpstreamConfig `json:"upstreamConfig"`
+
+	// InjectRequestHeaders are the headers to add to requests, by name.
+	InjectRequestHeaders map[string]HeaderValue `json:"injectRequestHeaders,omitempty"`
+
+	Server `json:",inline"`
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	// BindAddress is the address the server listens on.
+	BindAddress string `json:"bindAddress"`
+}
+
+// UpstreamConfig configures the upstream servers.
+type UpstreamConfig struct {
+	// Upstreams are the servers requests are proxied to.
+	Upstreams []Upstream `json:"upstreams"`
+}
+
+// Upstream is a server requests are proxied to.
+type Upstream struct {
+	// Timeout is the time to wait for a response.
+	Timeout string `json:"timeout"`
+
+	Weight int `json:"weight"`
+
+	// Rewrite rewrites the path of requests before they are proxied.
+	Rewrite *Rule `json:"rewrite,omitempty"`
+}
+
+// Rule matches requests, and may contain further rules.
+type Rule struct {
+	// Pattern matches the path of the request.
+	Pattern string `json:"pattern"`
+
+	// Rules are checked when the pattern matches.
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// HeaderValue is a value of a header.
+type HeaderValue struct {
+	// Value is the literal value of the header.
+	Value string `json:"value"`
+}
@@ -0,0 +1,72 @@
+package recursive
+
+// Config is the root of the configuration.
+type Config struct {
+	// Rule matches the requests to proxy.
+	Rule Rule `json:"rule"`
+
+	// Route routes the requests to a backend.
+	Route Route `json:"route"`
+
+	// Menu is the navigation shown on the sign in page.
+	Menu Menu `json:"menu,omitempty"`
+
+	// Chain is a chain of handlers.
+	Chain Chain `json:"chain,omitempty"`
+}
+
+// Rule matches requests, and may negate another rule.
+type Rule struct {
+	// Pattern matches the path of the request.
+	Pattern string `json:"pattern"`
+
+	// Not matches requests that the rule does not match.
+	Not *Rule `json:"not,omitempty"`
+}
+
+// Route routes requests to a backend.
+type Route struct {
+	// Path is the path prefix of the requests routed.
+	Path string `json:"path"`
+
+	// Backend serves the requests.
+	Backend *Backend `json:"backend,omitempty"`
+}
+
+// Backend serves requests, and may fall back to another route.
+type Backend struct {
+	// URL is the address of the backend.
+	URL string `json:"url"`
+
+	// Fallback routes the requests the backend fails to serve.
+	Fallback *Route `json:"fallback,omitempty"`
+}
+
+// Menu is a navigation menu.
+type Menu struct {
+	// Items are the entries of the menu.
+	Items []MenuItem `json:"items,omitempty"`
+}
+
+// MenuItem is an entry of a navigation menu, which may open a submenu.
+type MenuItem struct {
+	// Title is the text of the entry.
+	Title string `json:"title"`
+
+	// Submenu is the menu opened by the entry.
+	Submenu *Menu `json:"submenu,omitempty"`
+}
+
+// Chain is a handler that embeds a pointer to its own type.
+type Chain struct {
+	// Handler is the name of the handler.
+	Handler string `json:"handler"`
+
+	*Chain
+}
+
+// Unrelated is not reachable from the configuration.
+type Unrelated struct {
+	// Name is not documented.
+	Name string `json:"name"`
+}
@@ -0,0 +1,7 @@
+package api
+
+// Config configures the first API.
+type Config struct {
+	// Endpoint is the endpoint of the first API.
+	Endpoint string `json:"endpoint"`
+}
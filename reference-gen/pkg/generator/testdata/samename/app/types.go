@@ -0,0 +1,15 @@
+package app
+
+import (
+	first "github.com/oauth2-proxy/tools/reference-gen/pkg/generator/testdata/samename/a/api"
+	second "github.com/oauth2-proxy/tools/reference-gen/pkg/generator/testdata/samename/b/api"
+)
+
+// Options configures the clients of both APIs.
+type Options struct {
+	// First configures the first API.
+	First first.Config `json:"first"`
+
+	// Second configures the second API.
+	Second second.Config `json:"second"`
+}
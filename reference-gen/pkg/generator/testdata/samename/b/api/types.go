@@ -0,0 +1,7 @@
+package api
+
+// Config configures the second API.
+type Config struct {
+	// Token authenticates requests to the second API.
+	Token string `json:"token"`
+}
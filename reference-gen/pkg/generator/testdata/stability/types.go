@@ -0,0 +1,57 @@
+package stability
+
+// Options is the root of the configuration.
+type Options struct {
+	// Server configures the HTTP server.
+	Server Server `json:"server"`
+
+	// Tracing configures distributed tracing.
+	// +reference-gen:stability=alpha
+	// +optional
+	Tracing *Tracing `json:"tracing,omitempty"`
+
+	// Metrics configures the metrics server.
+	// +optional
+	Metrics *Metrics `json:"metrics,omitempty"`
+
+	// Samplers decide which requests are traced.
+	// +optional
+	Samplers []*Sampler `json:"samplers,omitempty"`
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	// BindAddress is the address the server listens on.
+	BindAddress string `json:"bindAddress"`
+
+	// EnableHTTP3 enables support for HTTP/3.
+	// +reference-gen:stability=beta
+	// +optional
+	EnableHTTP3 bool `json:"enableHTTP3,omitempty"`
+}
+
+// Tracing configures distributed tracing.
+type Tracing struct {
+	// Exporter configures where traces are sent.
+	Exporter TracingExporter `json:"exporter"`
+}
+
+// TracingExporter configures where traces are sent.
+type TracingExporter struct {
+	// Endpoint is the address of the trace collector.
+	Endpoint string `json:"endpoint"`
+}
+
+// Metrics configures the metrics server.
+// +reference-gen:stability=beta
+type Metrics struct {
+	// BindAddress is the address the metrics server listens on.
+	BindAddress string `json:"bindAddress"`
+}
+
+// Sampler decides which requests are traced.
+// +reference-gen:stability=alpha
+type Sampler struct {
+	// Ratio is the ratio of requests that are traced.
+	Ratio string `json:"ratio"`
+}
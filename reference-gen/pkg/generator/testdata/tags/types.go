@@ -0,0 +1,31 @@
+package tags
+
+// Options are the options of the proxy, loaded from flags, a config file or
+// the environment.
+type Options struct {
+	// ProxyPrefix is the prefix of the endpoints of the proxy.
+	ProxyPrefix string `flag:"proxy-prefix" cfg:"proxy_prefix" mapstructure:"proxy_prefix" toml:"proxyPrefix" json:"proxyPrefix,omitempty"`
+
+	// PingPath is the path of the health check endpoint.
+	PingPath string `flag:"ping-path" toml:"ping_path"`
+
+	// ClientSecret is the secret of the OAuth client.
+	// It is never marshalled to JSON.
+	ClientSecret string `flag:"client-secret" mapstructure:"client_secret" json:"-"`
+
+	// Cookie configures the session cookie.
+	Cookie CookieOptions `mapstructure:",squash"`
+
+	// Upstreams are the upstream servers to proxy to.
+	Upstreams []string `mapstructure:"upstreams" json:"upstreams"`
+}
+
+// CookieOptions configures the session cookie.
+type CookieOptions struct {
+	// Name is the name of the cookie.
+	Name string `flag:"cookie-name" mapstructure:"cookie_name" json:"name"`
+
+	// Secure marks the cookie as only sent over HTTPS.
+	// +reference-gen:field-tag=json
+	Secure bool `flag:"cookie-secure" mapstructure:"cookie_secure" json:"secure"`
+}
@@ -0,0 +1,3 @@
+{{ define "stability" -}}
+<span class="badge badge--warning">{{ . }}</span>
+{{- end }}
@@ -0,0 +1,3 @@
+{{ define "member_paths" -}}
+{{ with tag . "flag" }}<br/>Flag: {{ escapeCell (backtick (printf "--%s" .)) }}{{ end }}
+{{- end }}
@@ -0,0 +1,4 @@
+{{- define "package" -}}
+# Configuration overview
+{{ template "toc" . }}
+{{- end -}}
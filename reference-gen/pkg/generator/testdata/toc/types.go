@@ -0,0 +1,34 @@
+package toc
+
+// Options configures the proxy. It is loaded from the main configuration file.
+type Options struct {
+	// Server configures the HTTP server.
+	Server Server `json:"server"`
+
+	// Upstreams are the servers requests are proxied to.
+	Upstreams []Upstream `json:"upstreams"`
+}
+
+// AlphaOptions configures features of the proxy that are not yet stable.
+type AlphaOptions struct {
+	// Server configures the HTTP server.
+	Server Server `json:"server"`
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	// TLS configures serving over HTTPS.
+	TLS *TLS `json:"tls,omitempty"`
+}
+
+// TLS configures serving over HTTPS.
+type TLS struct {
+	// Key is the path to the private key | certificate pair.
+	Key string `json:"key"`
+}
+
+// Upstream is a server requests are proxied to.
+type Upstream struct {
+	// URI is the address of the upstream.
+	URI string `json:"uri"`
+}
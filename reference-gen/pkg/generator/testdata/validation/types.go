@@ -0,0 +1,36 @@
+package validation
+
+// Upstream configures an upstream server.
+type Upstream struct {
+	// ID identifies the upstream.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=^[a-z0-9-]+$
+	ID string `json:"id"`
+
+	// URI is the address of the upstream.
+	// +reference-gen:validation:Format=uri
+	URI string `json:"uri"`
+
+	// Weight is the share of requests sent to the upstream.
+	// Our own markers take priority over kubebuilder markers.
+	// +kubebuilder:validation:Minimum=0
+	// +reference-gen:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:validation:ExclusiveMaximum=true
+	// +optional
+	Weight int `json:"weight,omitempty"`
+
+	// Methods are the HTTP methods proxied to the upstream.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:UniqueItems=true
+	Methods []string `json:"methods"`
+
+	// Scheme is the scheme used to connect to the upstream.
+	// +kubebuilder:validation:Enum=http;https
+	Scheme string `json:"scheme"`
+
+	// Timeout has no constraints.
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+}
@@ -0,0 +1,8 @@
+package validationinvalid
+
+// Upstream has a validation marker that cannot be parsed.
+type Upstream struct {
+	// Weight is the share of requests sent to the upstream.
+	// +kubebuilder:validation:Minimum=one
+	Weight int `json:"weight"`
+}
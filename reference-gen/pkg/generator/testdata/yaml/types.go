@@ -32,7 +32,7 @@ type MyTestStruct struct {
 	SubStructMap map[string]SomeSubStruct `yaml:"subStructMap"`
 
 	// AnEmbeddedStruct is embedded here.
-	AnEmbeddedStruct
+	AnEmbeddedStruct `yaml:",inline"`
 
 	// AliasedDuration is a type alias to a duration.
 	AliasedDuration MyDuration `yaml:"aliasedDuration"`
@@ -63,9 +63,13 @@ type MyTestStruct struct {
 
 // SomeSubStruct is a struct to go within another struct.
 type SomeSubStruct struct {
-	// NonTaggedField doesn't have a tag, so the name will be capitalised.
+	// NonTaggedField doesn't have a tag, so it is named as the tags of the
+	// other fields of the struct would name it.
 	NonTaggedField bool
 
+	// TaggedField has a tag, which sets the format of the struct.
+	TaggedField bool `yaml:"taggedField"`
+
 	// privateStruct should not be included in the docs.
 	privateStruct PrivateMembers
 }
@@ -96,3 +100,61 @@ type MyDurationString time.Duration
 
 // AliasedExternalMap is an alias type for a map type outside of the package.
 type AliasedExternalMap template.FuncMap
+
+// FieldSemantics has fields that are marshalled differently to their names.
+type FieldSemantics struct {
+	// Ignored is left out when marshalled.
+	Ignored string `yaml:"-"`
+
+	// Dash is named "-" by the comma following the name.
+	Dash string `yaml:"-,"`
+
+	// Optional is left out when it is empty.
+	Optional string `yaml:"optional,omitempty"`
+
+	// Inline is inlined by its inline option.
+	Inline InlineStruct `yaml:",inline"`
+
+	// NamedEmbedded has a name, so its members are not embedded.
+	NamedEmbedded `yaml:"named"`
+
+	// PointerEmbedded is embedded by a pointer.
+	*PointerEmbedded `yaml:",inline"`
+
+	// unexportedEmbedded is unexported, but its members are still embedded.
+	unexportedEmbedded `yaml:",inline"`
+
+	// NotInlined is anonymous, but without the inline option it is marshalled
+	// under its lowercased name.
+	NotInlined
+}
+
+// InlineStruct is a struct that may be inlined.
+type InlineStruct struct {
+	// InlineValue is a member of the inlined struct.
+	InlineValue string `yaml:"inlineValue"`
+}
+
+// NamedEmbedded is embedded with a name.
+type NamedEmbedded struct {
+	// NamedValue is a member of the named struct.
+	NamedValue string `yaml:"namedValue"`
+}
+
+// PointerEmbedded is embedded by a pointer.
+type PointerEmbedded struct {
+	// PointerValue is a member of the struct embedded by a pointer.
+	PointerValue string `yaml:"pointerValue,omitempty"`
+}
+
+// unexportedEmbedded is an unexported struct that is embedded.
+type unexportedEmbedded struct {
+	// UnexportedValue is a member of the unexported struct.
+	UnexportedValue string `yaml:"unexportedValue"`
+}
+
+// NotInlined is embedded without the inline option.
+type NotInlined struct {
+	// NotInlinedValue is a member of the struct that is not inlined.
+	NotInlinedValue string `yaml:"notInlinedValue"`
+}
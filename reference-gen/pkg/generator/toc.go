@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"k8s.io/gengo/v2/types"
+)
+
+// tocGroup is a root type of the configuration, along with the types that
+// can be reached from it, for the table of contents.
+type tocGroup struct {
+	Root  *types.Type
+	Types []*types.Type
+}
+
+// tableOfContentsFunc constructs a tableOfContents function for the template
+func tableOfContentsFunc(references map[*types.Type][]*types.Type, knownTypes *typeIndex) func(typeList []*types.Type) []tocGroup {
+	return func(typeList []*types.Type) []tocGroup {
+		return tableOfContents(typeList, references, knownTypes)
+	}
+}
+
+// tableOfContents groups the visible types by the root types they can be
+// reached from. Roots are the types that are not referenced by other
+// documented types, along with types only reachable from each other.
+// A type reachable from several roots is listed under each of them.
+func tableOfContents(typeList []*types.Type, references map[*types.Type][]*types.Type, knownTypes *typeIndex) []tocGroup {
+	visible := visibleTypes(sortTypes(append([]*types.Type{}, typeList...)))
+	isVisible := newTypeSetFromList(visible)
+
+	// Invert the references, from each type to the types it references.
+	referenced := make(map[*types.Type][]*types.Type)
+	for _, t := range visible {
+		for _, ref := range typeReferences(t, references, knownTypes) {
+			if ref != t && isVisible.has(ref) {
+				referenced[ref] = append(referenced[ref], t)
+			}
+		}
+	}
+
+	reachableFrom := func(root *types.Type) typeSet {
+		reachable := make(typeSet)
+		var visit func(t *types.Type)
+		visit = func(t *types.Type) {
+			for _, ref := range referenced[t] {
+				if !reachable.has(ref) && ref != root {
+					reachable.add(ref)
+					visit(ref)
+				}
+			}
+		}
+		visit(root)
+		return reachable
+	}
+
+	var groups []tocGroup
+	listed := make(typeSet)
+	addGroup := func(root *types.Type) {
+		group := tocGroup{Root: root}
+		listed.add(root)
+		reachable := reachableFrom(root)
+		for _, t := range visible {
+			if reachable.has(t) {
+				group.Types = append(group.Types, t)
+				listed.add(t)
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	for _, t := range visible {
+		if !referencedByOthers(t, typeReferences(t, references, knownTypes)) {
+			addGroup(t)
+		}
+	}
+	for _, t := range visible {
+		if !listed.has(t) {
+			addGroup(t)
+		}
+	}
+	return groups
+}
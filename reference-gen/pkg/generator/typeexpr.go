@@ -0,0 +1,272 @@
+package generator
+
+import (
+	"fmt"
+	gotypes "go/types"
+	"strings"
+
+	"k8s.io/gengo/v2/types"
+)
+
+// typeSegment is a part of a rendered type expression. Segments naming a type
+// with documentation to link to carry the link.
+type typeSegment struct {
+	text string
+	link string
+}
+
+// typeExpression renders types as Go type expressions, such as
+// []map[string]Upstream, naming each type within the expression by its
+// display name and linking it to its documentation.
+// Pointers are not shown, as they make no difference to the configuration.
+type typeExpression struct {
+	knownTypes *typeIndex
+	links      externalLinks
+	segments   []typeSegment
+}
+
+// renderTypeExpression renders the type into the segments of its expression.
+func renderTypeExpression(t *types.Type, knownTypes *typeIndex, links externalLinks) []typeSegment {
+	e := &typeExpression{knownTypes: knownTypes, links: links}
+	e.write(t)
+	return e.segments
+}
+
+// write appends the expression of the type. Named types are written by name,
+// unnamed types are written from the expressions of the types within them.
+func (e *typeExpression) write(t *types.Type) {
+	if t == nil {
+		return
+	}
+	if isNamedType(t) {
+		e.writeNamed(t)
+		return
+	}
+	e.writeComposite(t)
+}
+
+// writeComposite appends the expression of a composite type from the types
+// within it, whether the type is named or not.
+func (e *typeExpression) writeComposite(t *types.Type) {
+	switch t.Kind {
+	case types.Pointer:
+		e.write(t.Elem)
+	case types.Slice:
+		e.text("[]")
+		e.write(t.Elem)
+	case types.Array:
+		e.text(fmt.Sprintf("[%d]", t.Len))
+		e.write(t.Elem)
+	case types.Map:
+		e.text("map[")
+		e.write(t.Key)
+		e.text("]")
+		e.write(t.Elem)
+	case types.Chan:
+		dir := chanDir(t)
+		switch dir {
+		case gotypes.SendOnly:
+			e.text("chan<- ")
+		case gotypes.RecvOnly:
+			e.text("<-chan ")
+		default:
+			e.text("chan ")
+		}
+		if dir == gotypes.SendRecv && chanDir(t.Elem) == gotypes.RecvOnly && !isNamedType(t.Elem) {
+			// chan <-chan T would be read as chan<- chan T.
+			e.text("(")
+			e.write(t.Elem)
+			e.text(")")
+			return
+		}
+		e.write(t.Elem)
+	case types.Func:
+		e.writeSignature(t.Signature)
+	}
+}
+
+// chanDir returns the direction of the channel type, from the type it was
+// built from. Types that are not channels are sent and received.
+func chanDir(t *types.Type) gotypes.ChanDir {
+	if t == nil || t.GoType == nil {
+		return gotypes.SendRecv
+	}
+	if c, ok := t.GoType.Underlying().(*gotypes.Chan); ok {
+		return c.Dir()
+	}
+	return gotypes.SendRecv
+}
+
+// writeNamed appends the display name of a named type, along with its link.
+func (e *typeExpression) writeNamed(t *types.Type) {
+	segment := typeSegment{text: qualifiedName(t)}
+	if e.knownTypes.has(t) {
+		segment.text = e.knownTypes.name(t)
+		segment.link = e.knownTypes.link(t)
+	} else if common, ok := e.knownTypes.commonType(t); ok {
+		// Common types are displayed by an alternate name, only link them
+		// when they have a link of their own.
+		segment.text = common.Name
+		segment.link = common.Link
+	} else {
+		segment.link = e.links.linkFor(t)
+	}
+
+	if segment.link == "" {
+		e.text(segment.text)
+		return
+	}
+	e.segments = append(e.segments, segment)
+}
+
+// writeSignature appends the parameters and results of a function.
+func (e *typeExpression) writeSignature(s *types.Signature) {
+	e.text("func(")
+	if s != nil {
+		for i, p := range s.Parameters {
+			if i > 0 {
+				e.text(", ")
+			}
+			if s.Variadic && i == len(s.Parameters)-1 && p.Type.Kind == types.Slice {
+				e.text("...")
+				e.write(p.Type.Elem)
+				continue
+			}
+			e.write(p.Type)
+		}
+	}
+	e.text(")")
+	if s == nil || len(s.Results) == 0 {
+		return
+	}
+
+	e.text(" ")
+	if len(s.Results) > 1 {
+		e.text("(")
+	}
+	for i, r := range s.Results {
+		if i > 0 {
+			e.text(", ")
+		}
+		e.write(r.Type)
+	}
+	if len(s.Results) > 1 {
+		e.text(")")
+	}
+}
+
+// text appends unlinked text to the expression.
+func (e *typeExpression) text(s string) {
+	if n := len(e.segments); n > 0 && e.segments[n-1].link == "" {
+		e.segments[n-1].text += s
+		return
+	}
+	e.segments = append(e.segments, typeSegment{text: s})
+}
+
+// referencedTypes returns the named types within the expression of the type,
+// such as the key and value types of a map, in the order they are written.
+func referencedTypes(t *types.Type) []*types.Type {
+	if t == nil {
+		return nil
+	}
+	if isNamedType(t) {
+		return []*types.Type{t}
+	}
+
+	switch t.Kind {
+	case types.Map:
+		return append(referencedTypes(t.Key), referencedTypes(t.Elem)...)
+	case types.Func:
+		var out []*types.Type
+		if t.Signature != nil {
+			for _, p := range t.Signature.Parameters {
+				out = append(out, referencedTypes(p.Type)...)
+			}
+			for _, r := range t.Signature.Results {
+				out = append(out, referencedTypes(r.Type)...)
+			}
+		}
+		return out
+	default:
+		return referencedTypes(t.Elem)
+	}
+}
+
+// isCompositeType determines if the type is built from the types within it,
+// such as a slice or map.
+func isCompositeType(t *types.Type) bool {
+	switch t.Kind {
+	case types.Pointer, types.Slice, types.Array, types.Map, types.Chan, types.Func:
+		return true
+	default:
+		return false
+	}
+}
+
+// isNamedType determines if the type has a name of its own, rather than
+// being written as an expression of the types within it.
+// Unnamed types belong to no package.
+func isNamedType(t *types.Type) bool {
+	return !isCompositeType(t) || t.Name.Package != ""
+}
+
+// qualifiedName produces the name of the type in the form of <pkg>.<type>.
+func qualifiedName(t *types.Type) string {
+	// The parser names the empty interface "interface{}", display it as "any"
+	// as the go toolchain does.
+	return strings.ReplaceAll(t.Name.String(), "interface{}", "any")
+}
+
+// typeSegmentsText joins the text of the segments of a type expression.
+func typeSegmentsText(segments []typeSegment) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
+
+// typeSegmentsLink returns the link of a type expression that links to a
+// single type, or an empty string when it links to none or several types.
+func typeSegmentsLink(segments []typeSegment) string {
+	link := ""
+	for _, s := range segments {
+		if s.link == "" || s.link == link {
+			continue
+		}
+		if link != "" {
+			return ""
+		}
+		link = s.link
+	}
+	return link
+}
+
+// typeSegmentsMarkdown renders a type expression as Markdown, escaping the
+// text with the escape function given.
+// An expression that links to a single type is linked as a whole, as in
+// [[]Upstream](#upstream). Otherwise each type is linked on its own, and the
+// brackets of the expression are escaped so that they are not read as links.
+func typeSegmentsMarkdown(segments []typeSegment, escape func(string) string) string {
+	if link := typeSegmentsLink(segments); link != "" {
+		return "[" + escape(typeSegmentsText(segments)) + "](" + link + ")"
+	}
+	if len(segments) <= 1 {
+		// Text without links is joined into a single segment.
+		return escape(typeSegmentsText(segments))
+	}
+
+	var b strings.Builder
+	for _, s := range segments {
+		if s.link != "" {
+			b.WriteString("[" + escape(s.text) + "](" + s.link + ")")
+			continue
+		}
+		b.WriteString(bracketEscaper.Replace(escape(s.text)))
+	}
+	return b.String()
+}
+
+// bracketEscaper escapes the brackets of type expressions between links.
+var bracketEscaper = strings.NewReplacer("[", `\[`, "]", `\]`)
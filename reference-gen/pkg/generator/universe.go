@@ -0,0 +1,275 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	gotypes "go/types"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"k8s.io/gengo/v2/types"
+)
+
+// universeBuilder builds the gengo types of the loaded packages from the
+// types and syntax loaded by go/packages, in the same form as the gengo
+// parser, so that the packages are only loaded and type checked once.
+// Only the parts of the types used by the generator are built: the methods
+// of types and the variables of packages are left out.
+type universeBuilder struct {
+	universe types.Universe
+	fset     *token.FileSet
+	// comments holds the comment groups of the loaded files, keyed by the
+	// file and line that they end on, to find the doc comment before a
+	// declaration or field.
+	comments map[fileLine]*ast.CommentGroup
+}
+
+// fileLine is a line within a file.
+type fileLine struct {
+	file string
+	line int
+}
+
+// buildUniverse converts the loaded packages to gengo packages. The types of
+// the packages, and the types that they refer to, are added to the universe.
+func buildUniverse(fset *token.FileSet, loaded []*packages.Package) []*types.Package {
+	b := &universeBuilder{
+		universe: types.Universe{},
+		fset:     fset,
+		comments: make(map[fileLine]*ast.CommentGroup),
+	}
+	// Types may refer to the types of other packages, which are documented
+	// with their comments when they are linked or embedded.
+	packages.Visit(loaded, nil, func(pkg *packages.Package) {
+		for _, f := range pkg.Syntax {
+			for _, c := range f.Comments {
+				position := fset.Position(c.End())
+				b.comments[fileLine{position.Filename, position.Line}] = c
+			}
+		}
+	})
+
+	out := make([]*types.Package, 0, len(loaded))
+	for _, pkg := range loaded {
+		out = append(out, b.addPackage(pkg))
+	}
+	return out
+}
+
+// addPackage adds the types, functions and constants declared by the package.
+func (b *universeBuilder) addPackage(pkg *packages.Package) *types.Package {
+	p := b.universe.Package(pkg.PkgPath)
+	p.Name = pkg.Name
+	if len(pkg.GoFiles) > 0 {
+		p.Dir = filepath.Dir(pkg.GoFiles[0])
+	}
+
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		switch obj := scope.Lookup(name).(type) {
+		case *gotypes.TypeName:
+			t := b.walkType(nil, obj.Type())
+			b.addComments(obj, t)
+		case *gotypes.Func:
+			out := b.universe.Function(types.Name{Package: pkg.PkgPath, Name: obj.Name()})
+			out.Kind = types.DeclarationOf
+			out.Underlying = b.walkType(nil, obj.Type())
+			b.addComments(obj, out)
+		case *gotypes.Const:
+			b.addConstant(pkg.PkgPath, obj)
+		}
+	}
+	return p
+}
+
+// addConstant adds the constant, along with its value, to the universe.
+func (b *universeBuilder) addConstant(pkgPath string, obj *gotypes.Const) {
+	out := b.universe.Constant(types.Name{Package: pkgPath, Name: obj.Name()})
+	out.Kind = types.DeclarationOf
+	out.Underlying = b.walkType(nil, obj.Type())
+	b.addComments(obj, out)
+
+	// Strings are unquoted, other values are rendered as they are written.
+	value := obj.Val().String()
+	if obj.Val().Kind() == constant.String {
+		value = constant.StringVal(obj.Val())
+	}
+	out.ConstValue = &value
+}
+
+// addComments attaches the doc comment of the declaration to the type.
+// The comments of aliases only apply to types without comments of their own.
+func (b *universeBuilder) addComments(obj gotypes.Object, t *types.Type) {
+	lines := b.docComment(obj.Pos())
+	if len(lines) == 0 {
+		return
+	}
+	if _, alias := obj.Type().(*gotypes.Alias); alias && len(t.CommentLines) > 0 {
+		return
+	}
+	t.CommentLines = lines
+}
+
+// docComment returns the lines of the comment ending on the line before the
+// position, which is the doc comment of a declaration or field.
+func (b *universeBuilder) docComment(pos token.Pos) []string {
+	position := b.fset.Position(pos)
+	c, ok := b.comments[fileLine{position.Filename, position.Line - 1}]
+	if !ok {
+		return nil
+	}
+	text := strings.TrimRight(c.Text(), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// walkType adds the type, and the types within it, to the universe.
+// Named types whose underlying type is a struct, interface, pointer, array,
+// channel or function take the kind of their underlying type, while other
+// named types are aliases of their underlying type.
+func (b *universeBuilder) walkType(useName *types.Name, in gotypes.Type) *types.Type {
+	name := goNameToName(in.String())
+	if useName != nil {
+		name = *useName
+	}
+
+	switch t := in.(type) {
+	case *gotypes.Alias:
+		return b.walkType(nil, gotypes.Unalias(t))
+	case *gotypes.Basic:
+		// Builtin types belong to the package without a path.
+		out := b.universe.Type(types.Name{Name: t.Name()})
+		if out.Kind == types.Unknown {
+			out.Kind = types.Unsupported
+		}
+		return out
+	case *gotypes.Named:
+		return b.walkNamed(t)
+	case *gotypes.TypeParam:
+		// Type parameters are only named within their type, they are not
+		// added to the universe.
+		return &types.Type{Name: name, Kind: types.TypeParam}
+	}
+
+	out := b.universe.Type(name)
+	out.GoType = in
+	if out.Kind != types.Unknown {
+		return out
+	}
+
+	switch t := in.(type) {
+	case *gotypes.Struct:
+		out.Kind = types.Struct
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			out.Members = append(out.Members, types.Member{
+				Name:         f.Name(),
+				Embedded:     f.Anonymous(),
+				Tags:         t.Tag(i),
+				Type:         b.walkType(nil, f.Type()),
+				CommentLines: b.docComment(f.Pos()),
+			})
+		}
+	case *gotypes.Map:
+		out.Kind = types.Map
+		out.Elem = b.walkType(nil, t.Elem())
+		out.Key = b.walkType(nil, t.Key())
+	case *gotypes.Pointer:
+		out.Kind = types.Pointer
+		out.Elem = b.walkType(nil, t.Elem())
+	case *gotypes.Slice:
+		out.Kind = types.Slice
+		out.Elem = b.walkType(nil, t.Elem())
+	case *gotypes.Array:
+		out.Kind = types.Array
+		out.Elem = b.walkType(nil, t.Elem())
+		out.Len = t.Len()
+	case *gotypes.Chan:
+		out.Kind = types.Chan
+		out.Elem = b.walkType(nil, t.Elem())
+	case *gotypes.Signature:
+		out.Kind = types.Func
+		out.Signature = b.convertSignature(t)
+	case *gotypes.Interface:
+		out.Kind = types.Interface
+	default:
+		out.Kind = types.Unsupported
+	}
+	return out
+}
+
+// walkNamed adds the named type to the universe.
+func (b *universeBuilder) walkNamed(t *gotypes.Named) *types.Type {
+	name := goNameToName(t.String())
+	switch t.Underlying().(type) {
+	case *gotypes.Basic, *gotypes.Map, *gotypes.Slice:
+		out := b.universe.Type(name)
+		out.GoType = t
+		if out.Kind == types.Unknown {
+			out.Kind = types.Alias
+			out.Underlying = b.walkType(nil, t.Underlying())
+		}
+		return out
+	case *gotypes.Struct, *gotypes.Interface:
+		if t.TypeParams().Len() > 0 {
+			// Generic types are named by their type parameters, such as
+			// Foo[T], rather than by the constraints of the parameters.
+			var params []string
+			for i := 0; i < t.TypeParams().Len(); i++ {
+				params = append(params, t.TypeParams().At(i).Obj().Name())
+			}
+			name.Name = fmt.Sprintf("%s[%s]", strings.SplitN(name.Name, "[", 2)[0], strings.Join(params, ","))
+		}
+	}
+	if out := b.universe.Type(name); out.Kind != types.Unknown {
+		return out
+	}
+	// The type takes the kind, and members, of its underlying type.
+	return b.walkType(&name, t.Underlying())
+}
+
+// convertSignature converts the parameters and results of a function.
+func (b *universeBuilder) convertSignature(t *gotypes.Signature) *types.Signature {
+	signature := &types.Signature{Variadic: t.Variadic()}
+	for i := 0; i < t.Params().Len(); i++ {
+		signature.Parameters = append(signature.Parameters, &types.ParamResult{
+			Name: t.Params().At(i).Name(),
+			Type: b.walkType(nil, t.Params().At(i).Type()),
+		})
+	}
+	for i := 0; i < t.Results().Len(); i++ {
+		signature.Results = append(signature.Results, &types.ParamResult{
+			Name: t.Results().At(i).Name(),
+			Type: b.walkType(nil, t.Results().At(i).Type()),
+		})
+	}
+	return signature
+}
+
+// goNameToName converts the string of a go type to a gengo name.
+// Unnamed types, such as pointers and maps, are named by the whole string.
+// Named types are split into their package path and name.
+func goNameToName(in string) types.Name {
+	for _, prefix := range []string{"struct{", "<-chan", "chan<-", "chan ", "func(", "func (", "*", "map[", "["} {
+		if strings.HasPrefix(in, prefix) {
+			return types.Name{Name: in}
+		}
+	}
+
+	// The type arguments of generic types may contain '.' characters, split
+	// the package path from the name before them.
+	generic := strings.IndexRune(in, '[')
+	if generic == -1 {
+		generic = len(in)
+	}
+	dot := strings.LastIndex(in[:generic], ".")
+	if dot == -1 {
+		return types.Name{Name: in}
+	}
+	return types.Name{Package: in[:dot], Name: in[dot+1:]}
+}
@@ -2,19 +2,13 @@ package generator
 
 import (
 	"fmt"
-	"reflect"
+	"path"
 	"sort"
 	"strings"
 	"unicode"
 
-	"k8s.io/gengo/types"
-	"k8s.io/klog/v2"
-)
-
-var (
-	commonTypes = map[string]string{
-		"time.Duration": "duration",
-	}
+	gengo "k8s.io/gengo/v2"
+	"k8s.io/gengo/v2/types"
 )
 
 // BEGIN: stringSet
@@ -85,6 +79,176 @@ func newTypeSetFromList(typeList []*types.Type) typeSet {
 
 // END: typeSet
 
+// BEGIN: typeIndex
+
+// typeIndex is the set of types documented in the output, along with a
+// unique display name and anchor for each type.
+// Types are known by their name unless another documented type, from a
+// different package, shares the name. Such types are qualified with their
+// package name, or their package path if the package names also clash.
+// Types that are not documented may be displayed as a common type instead.
+// When each type is rendered into a file of its own, the index also holds the
+// name of the file of each type.
+type typeIndex struct {
+	types   typeSet
+	names   map[*types.Type]string
+	anchors map[*types.Type]string
+	// memberAnchors holds the anchors of the members of each type, keyed by
+	// their field name, which are unique among the anchors of the types.
+	memberAnchors map[*types.Type]map[string]string
+	files         map[*types.Type]string
+	common        commonTypes
+}
+
+func (i *typeIndex) has(required *types.Type) bool {
+	return i.types.has(required)
+}
+
+// commonType returns the common type that the type is displayed as.
+// Documented types are never displayed as a common type.
+func (i *typeIndex) commonType(t *types.Type) (commonType, bool) {
+	if i.has(tryDereference(t)) {
+		return commonType{}, false
+	}
+	return i.common.lookup(t)
+}
+
+// name returns the unique display name of the type.
+func (i *typeIndex) name(t *types.Type) string {
+	return i.names[t]
+}
+
+// anchor returns the unique anchor for the type.
+func (i *typeIndex) anchor(t *types.Type) string {
+	return i.anchors[t]
+}
+
+// link returns the link to the documentation of the type, either to its file
+// or to its section of the document.
+func (i *typeIndex) link(t *types.Type) string {
+	if file, ok := i.files[t]; ok {
+		return file
+	}
+	return "#" + i.anchor(t)
+}
+
+// splitFiles names a file for each type, from the anchor of the type and the
+// extension given. Types are not given the name reserved for the index.
+func (i *typeIndex) splitFiles(index, ext string) {
+	i.files = make(map[*types.Type]string)
+	for t, anchor := range i.anchors {
+		if anchor == index {
+			anchor += "-type"
+		}
+		i.files[t] = anchor + ext
+	}
+}
+
+func newTypeIndex(typeList []*types.Type, common commonTypes, priority []string) *typeIndex {
+	index := &typeIndex{
+		types:         newTypeSetFromList(typeList),
+		names:         make(map[*types.Type]string),
+		anchors:       make(map[*types.Type]string),
+		memberAnchors: make(map[*types.Type]map[string]string),
+		common:        common,
+	}
+
+	byName := make(map[string][]*types.Type)
+	for _, t := range typeList {
+		byName[t.Name.Name] = append(byName[t.Name.Name], t)
+	}
+
+	for name, typs := range byName {
+		if len(typs) == 1 {
+			index.names[typs[0]] = name
+			index.anchors[typs[0]] = strings.ToLower(name)
+			continue
+		}
+
+		byPackageName := make(map[string]int)
+		for _, t := range typs {
+			byPackageName[path.Base(t.Name.Package)]++
+		}
+		for _, t := range typs {
+			qualifier := path.Base(t.Name.Package)
+			if byPackageName[qualifier] > 1 {
+				qualifier = t.Name.Package
+			}
+			index.names[t] = qualifier + "." + name
+			index.anchors[t] = anchorReplacer.Replace(strings.ToLower(qualifier + "-" + name))
+		}
+	}
+
+	index.addMemberAnchors(typeList, priority)
+	return index
+}
+
+// addMemberAnchors gives each visible member of the types an anchor from the
+// anchor of its type and its field name. Members of embedded types belong to
+// the type embedding them. An anchor that is already taken, by a type or an
+// earlier member, is made unique with a numeric suffix, as the anchor of the
+// field bar of type Foo would otherwise be that of a type anchored foo-bar.
+func (i *typeIndex) addMemberAnchors(typeList []*types.Type, priority []string) {
+	taken := make(map[string]bool)
+	for _, anchor := range i.anchors {
+		taken[anchor] = true
+	}
+	for _, t := range sortTypes(append([]*types.Type{}, typeList...)) {
+		anchors := make(map[string]string)
+		for _, name := range memberFieldNames(t, priority, newTypeSetFromList([]*types.Type{t})) {
+			if _, ok := anchors[name]; ok {
+				continue
+			}
+			base := i.anchors[t] + "-" + anchorReplacer.Replace(strings.ToLower(name))
+			anchor := base
+			for n := 2; taken[anchor]; n++ {
+				anchor = fmt.Sprintf("%s-%d", base, n)
+			}
+			taken[anchor] = true
+			anchors[name] = anchor
+		}
+		i.memberAnchors[t] = anchors
+	}
+}
+
+// memberAnchor returns the anchor of the member of the type with the field
+// name given.
+func (i *typeIndex) memberAnchor(t *types.Type, name string) string {
+	if anchor, ok := i.memberAnchors[t][name]; ok {
+		return anchor
+	}
+	return i.anchors[t] + "-" + anchorReplacer.Replace(strings.ToLower(name))
+}
+
+// memberFieldNames lists the field names of the visible members of the type,
+// including the members of the types it embeds, in the order they are declared.
+func memberFieldNames(t *types.Type, priority []string, embedding typeSet) []string {
+	var out []string
+	for _, m := range t.Members {
+		if hideMember(m, priority) {
+			continue
+		}
+		if !fieldEmbedded(m, priority) {
+			out = append(out, fieldName(m, t, priority))
+			continue
+		}
+		embedded := tryDereference(m.Type)
+		if embedding.has(embedded) {
+			continue
+		}
+		embedding.add(embedded)
+		out = append(out, memberFieldNames(embedded, priority, embedding)...)
+		delete(embedding, embedded)
+	}
+	return out
+}
+
+// anchorReplacer replaces the characters of a package path that are not
+// valid within an anchor.
+var anchorReplacer = strings.NewReplacer("/", "-", ".", "-", "_", "-")
+
+// END: typeIndex
+
 // tryDereference returns the underlying type when t is a pointer, map, or slice.
 func tryDereference(t *types.Type) *types.Type {
 	if t.Elem != nil {
@@ -93,6 +257,30 @@ func tryDereference(t *types.Type) *types.Type {
 	return t
 }
 
+// packageSection is the set of types to render for a single package.
+type packageSection struct {
+	Path  string
+	Name  string
+	Types []*types.Type
+}
+
+// packageSections groups the types by the package they belong to.
+// Packages without any types to render are omitted.
+func packageSections(pkgs []*types.Package, typeList []*types.Type) []packageSection {
+	byPackage := make(map[string][]*types.Type)
+	for _, t := range typeList {
+		byPackage[t.Name.Package] = append(byPackage[t.Name.Package], t)
+	}
+
+	var out []packageSection
+	for _, pkg := range pkgs {
+		if typs, ok := byPackage[pkg.Path]; ok {
+			out = append(out, packageSection{Path: pkg.Path, Name: pkg.Name, Types: typs})
+		}
+	}
+	return out
+}
+
 // createTypeList converts a map of types into a list of types
 func createTypeList(typesForList map[*types.Type][]*types.Type) []*types.Type {
 	out := []*types.Type{}
@@ -105,7 +293,7 @@ func createTypeList(typesForList map[*types.Type][]*types.Type) []*types.Type {
 // BEGIN: template functions
 
 // aliasDisplayNameFunc constructs a aliasDisplayName function for the template
-func aliasDisplayNameFunc(knownTypes typeSet) func(t *types.Type) string {
+func aliasDisplayNameFunc(knownTypes *typeIndex) func(t *types.Type) string {
 	return func(t *types.Type) string {
 		return aliasDisplayName(t, knownTypes)
 	}
@@ -114,51 +302,98 @@ func aliasDisplayNameFunc(knownTypes typeSet) func(t *types.Type) string {
 // aliasDisplayName allows types to replace their alias with an alternate
 // alias display name.
 // This can be useful when a type has a custom marshalling rule.
-func aliasDisplayName(t *types.Type, knownTypes typeSet) string {
-	tags := types.ExtractCommentTags("+", t.CommentLines)
-	if alias, ok := tags["reference-gen:alias-name"]; ok {
-		// There should only be one entry
-		return alias[0]
+func aliasDisplayName(t *types.Type, knownTypes *typeIndex) string {
+	if alias := aliasNameOverride(t); alias != "" {
+		return alias
 	}
 
 	if t.Underlying != nil {
 		return typeDisplayName(t.Underlying, knownTypes)
 	}
+	if isCompositeType(t) {
+		// Named arrays, channels and functions are not parsed as aliases.
+		e := &typeExpression{knownTypes: knownTypes}
+		e.writeComposite(t)
+		return typeSegmentsText(e.segments)
+	}
 
 	return ""
 }
 
-// anchorIDForLocalType returns the #anchor string for the local type
-func anchorIDForLocalType(t *types.Type) string {
-	return strings.ToLower(t.Name.Name)
+// aliasNameOverride returns the alias name set by the
+// +reference-gen:alias-name comment tag, if present.
+func aliasNameOverride(t *types.Type) string {
+	tags := gengo.ExtractCommentTags("+", t.CommentLines)
+	if alias, ok := tags["reference-gen:alias-name"]; ok {
+		// There should only be one entry
+		return alias[0]
+	}
+	return ""
 }
 
-// backtick wraps the text in backticks
+// backtick wraps the text in backticks.
+// Text containing backticks is wrapped in a longer run of backticks, padded
+// with spaces, so that it is kept as one code span.
 func backtick(s string) string {
-	return "`" + s + "`"
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	return fence + " " + s + " " + fence
 }
 
-// fieldEmbedded detemines if the field is embedded or not
-func fieldEmbedded(m types.Member) bool {
-	return m.Embedded
+// commonTypeDescriptionFunc constructs a commonTypeDescription function for the template
+func commonTypeDescriptionFunc(knownTypes *typeIndex) func(t *types.Type) string {
+	return func(t *types.Type) string {
+		common, _ := knownTypes.commonType(t)
+		return common.Description
+	}
 }
 
-// fieldName extracts the field name from the json tag
-func fieldName(m types.Member) string {
-	vj := reflect.StructTag(m.Tags).Get("json")
-	vj = strings.Split(vj, ",")[0]
-
-	if vj != "" {
-		return vj
+// fieldEmbeddedFunc constructs a fieldEmbedded function for the template
+func fieldEmbeddedFunc(priority []string) func(m types.Member) bool {
+	return func(m types.Member) bool {
+		return fieldEmbedded(m, priority)
 	}
+}
 
-	vy := reflect.StructTag(m.Tags).Get("yaml")
-	vy = strings.Split(vy, ",")[0]
-	if vy != "" {
-		return vy
+// fieldEmbedded determines if the members of the field are embedded into the
+// type, as they are when marshalled. Only structs, or pointers to structs,
+// are embedded. encoding/json, and toml, embed anonymous fields without a name
+// in their tag, while yaml embeds fields with the inline option and
+// mapstructure embeds fields with the squash option, even when anonymous.
+// Anonymous fields without a tag follow the rule of the tag their own members
+// are marshalled with, see untaggedKey.
+func fieldEmbedded(m types.Member, priority []string) bool {
+	tag := parseMemberTag(m, priority)
+	if tag.ignored || tryDereference(m.Type).Kind != types.Struct {
+		return false
+	}
+	switch tag.key {
+	case "yaml", "mapstructure":
+		return tag.inline
+	default:
+		return m.Embedded && tag.name == ""
 	}
+}
 
-	return m.Name
+// fieldName extracts the field name from the struct tag the field is
+// marshalled with, or uses the name of the field when the tag does not name it.
+// The owner is the struct declaring the member, whose tags decide the format
+// of its untagged members.
+func fieldName(m types.Member, owner *types.Type, priority []string) string {
+	tag := parseMemberTag(m, priority)
+	if tag.name != "" {
+		return tag.name
+	}
+	key := tag.key
+	if key == "" {
+		key = siblingTagKey(owner, m, priority)
+	}
+	return untaggedName(m, key)
 }
 
 // filterCommentTags removes comment hints before they are rendered
@@ -172,130 +407,151 @@ func filterCommentTags(comments []string) []string {
 	return out
 }
 
-// hideMember determines if a member is to private
-func hideMember(m types.Member) bool {
-	return unicode.IsLower(rune(m.Name[0]))
+// headingAnchorFunc constructs a headingAnchor function for the template
+func headingAnchorFunc(knownTypes *typeIndex) func(t *types.Type) string {
+	return func(t *types.Type) string {
+		return headingAnchor(t, knownTypes)
+	}
 }
 
-// hideType determines if a type is to private
+// headingAnchor returns the anchor for the type when it differs from the
+// anchor generated for the heading of the type section. Such anchors must be
+// added to the output explicitly.
+func headingAnchor(t *types.Type, knownTypes *typeIndex) string {
+	if anchor := knownTypes.anchor(t); anchor != strings.ToLower(t.Name.Name) {
+		return anchor
+	}
+	return ""
+}
+
+// hideMemberFunc constructs a hideMember function for the template
+func hideMemberFunc(priority []string) func(m types.Member) bool {
+	return func(m types.Member) bool {
+		return hideMember(m, priority)
+	}
+}
+
+// hideMember determines if a member is to private, is left out when
+// marshalled, or is hidden by a marker.
+func hideMember(m types.Member, priority []string) bool {
+	return isHidden(m.CommentLines) || !marshalledMember(m, priority)
+}
+
+// marshalledMember determines if a member is marshalled, as members that are
+// private or left out by their tag are not.
+// The exported fields of unexported embedded structs are still marshalled,
+// unless the struct is embedded by a pointer.
+func marshalledMember(m types.Member, priority []string) bool {
+	if parseMemberTag(m, priority).ignored {
+		return false
+	}
+	if unicode.IsLower(rune(m.Name[0])) {
+		return fieldEmbedded(m, priority) && m.Type.Kind != types.Pointer
+	}
+	return true
+}
+
+// hideType determines if a type is to private, or is hidden by a marker
 func hideType(t *types.Type) bool {
-	return unicode.IsLower(rune(t.Name.Name[0]))
+	return unicode.IsLower(rune(t.Name.Name[0])) || isHidden(t.CommentLines)
 }
 
-// isOptionalMember determines if a member is marked optional
-func isOptionalMember(m types.Member) bool {
-	tags := types.ExtractCommentTags("+", m.CommentLines)
-	_, ok := tags["optional"]
-	return ok
+// isOptionalMemberFunc constructs an isOptionalMember function for the template
+func isOptionalMemberFunc(priority []string, omitEmptyOptional bool) func(m types.Member) bool {
+	return func(m types.Member) bool {
+		return isOptionalMember(m, priority, omitEmptyOptional)
+	}
+}
+
+// isOptionalMember determines if a member is marked optional, or, when
+// omitempty implies optional, has the omitempty option in its tag.
+func isOptionalMember(m types.Member, priority []string, omitEmptyOptional bool) bool {
+	tags := gengo.ExtractCommentTags("+", m.CommentLines)
+	if _, ok := tags["optional"]; ok {
+		return true
+	}
+	return omitEmptyOptional && parseMemberTag(m, priority).omitEmpty
 }
 
 // linkForTypeFunc constructs a linkForType function for the template
-func linkForTypeFunc(knownTypes typeSet) func(t *types.Type) string {
+func linkForTypeFunc(knownTypes *typeIndex, links externalLinks) func(t *types.Type) string {
 	return func(t *types.Type) string {
-		return linkForType(t, knownTypes)
+		return linkForType(t, knownTypes, links)
 	}
 }
 
-// linkForType returns an anchor to the type if it can be generated. returns
-// empty string if it is not a local type or unrecognized external type.
-func linkForType(t *types.Type, knownTypes typeSet) string {
+// linkForType returns an anchor to the type if it can be generated, or a link
+// to the documentation of an external type. Types such as []Upstream link to
+// the type within them. returns empty string if it is not a local type or
+// unrecognized external type, or when several types within it have a link.
+func linkForType(t *types.Type, knownTypes *typeIndex, links externalLinks) string {
 	if t == nil {
 		return ""
 	}
+	return typeSegmentsLink(renderTypeExpression(t, knownTypes, links))
+}
 
-	t = tryDereference(t) // dereference kind=Pointer
-
-	if knownTypes.has(t) {
-		return "#" + anchorIDForLocalType(t)
+// linkedTypeDisplayNameFunc constructs a linkedTypeDisplayName function for
+// the template.
+func linkedTypeDisplayNameFunc(knownTypes *typeIndex, links externalLinks, escaper escaper) func(t *types.Type) string {
+	return func(t *types.Type) string {
+		return typeSegmentsMarkdown(renderTypeExpression(t, knownTypes, links), escaper.cell)
 	}
-
-	return ""
 }
 
 // renderComments filters comments and joins them to a single string using the
 // join sequence provided.
 func renderComments(s []string, join string) string {
-	s = filterCommentTags(s)
+	s = filterDeprecatedParagraph(filterCommentTags(s))
 	doc := strings.Join(s, join)
 	return doc
 }
 
-func renderCommentsBR(s []string) string {
-	return renderComments(s, "<br/>")
-}
-
 func renderCommentsLF(s []string) string {
 	return renderComments(s, "\n")
 }
 
-// sortTypes sorts types alphabetically
+// sortTypes sorts types alphabetically, types with the same name are
+// sorted by their package
 func sortTypes(typs []*types.Type) []*types.Type {
 	sort.Slice(typs, func(i, j int) bool {
 		t1, t2 := typs[i], typs[j]
-		return t1.Name.Name < t2.Name.Name
+		if t1.Name.Name != t2.Name.Name {
+			return t1.Name.Name < t2.Name.Name
+		}
+		return t1.Name.Package < t2.Name.Package
 	})
 	return typs
 }
 
 // typeDisplayNameFunc constructs a typeDisplayName function for the template
-func typeDisplayNameFunc(knownTypes typeSet) func(t *types.Type) string {
+func typeDisplayNameFunc(knownTypes *typeIndex) func(t *types.Type) string {
 	return func(t *types.Type) string {
 		return typeDisplayName(t, knownTypes)
 	}
 }
 
-// typeDisplayName works out the display name to be printed for a type
-func typeDisplayName(t *types.Type, knownTypes typeSet) string {
-	s := typeIdentifier(t)
-	dt := tryDereference(t)
-	if knownTypes.has(dt) {
-		s = dt.Name.Name
-	}
-	if t.Kind == types.Pointer {
-		s = strings.TrimLeft(s, "*")
-	}
-
-	switch t.Kind {
-	case types.Struct,
-		types.Interface,
-		types.Alias,
-		types.Pointer,
-		types.Slice,
-		types.Builtin:
-		// noop
-	case types.Map:
-		// construct map based on element name
-		return fmt.Sprintf("map[%s]%s", t.Key.Name.Name, s)
-	default:
-		klog.Fatalf("type %s has kind=%v which is unhandled", t.Name, t.Kind)
-	}
-
-	if alias, ok := commonTypes[s]; ok {
-		s = alias
-	}
-
-	if t.Kind == types.Slice {
-		s = "[]" + s
-	}
-
-	return s
+// typeDisplayName works out the display name to be printed for a type, as
+// the expression of the type with each type within it named by its display
+// name.
+func typeDisplayName(t *types.Type, knownTypes *typeIndex) string {
+	return typeSegmentsText(renderTypeExpression(t, knownTypes, nil))
 }
 
 // typeIdentifier produces the type ID in the form of <pkg>.<type>.
 func typeIdentifier(t *types.Type) string {
-	t = tryDereference(t)
-	return t.Name.String() // {PackagePath.Name}
+	return qualifiedName(tryDereference(t)) // {PackagePath.Name}
 }
 
 // typeReferencesFunc constructs a typeReferences function for the template
-func typeReferencesFunc(references map[*types.Type][]*types.Type, knownTypes typeSet) func(t *types.Type) []*types.Type {
+func typeReferencesFunc(references map[*types.Type][]*types.Type, knownTypes *typeIndex) func(t *types.Type) []*types.Type {
 	return func(t *types.Type) []*types.Type {
 		return typeReferences(t, references, knownTypes)
 	}
 }
 
 // typeReferences creates a list of types that reference the given type
-func typeReferences(t *types.Type, references map[*types.Type][]*types.Type, knownTypes typeSet) []*types.Type {
+func typeReferences(t *types.Type, references map[*types.Type][]*types.Type, knownTypes *typeIndex) []*types.Type {
 	out := []*types.Type{}
 	for _, typ := range references[t] {
 		if knownTypes.has(typ) {
@@ -306,11 +562,18 @@ func typeReferences(t *types.Type, references map[*types.Type][]*types.Type, kno
 	return out
 }
 
+// visibleMembersFunc constructs a visibleMembers function for the template
+func visibleMembersFunc(priority []string) func(in []types.Member) []types.Member {
+	return func(in []types.Member) []types.Member {
+		return visibleMembers(in, priority)
+	}
+}
+
 // visibleMembers filters the members to only those that are exported
-func visibleMembers(in []types.Member) []types.Member {
+func visibleMembers(in []types.Member, priority []string) []types.Member {
 	var out []types.Member
 	for _, t := range in {
-		if !hideMember(t) {
+		if !hideMember(t, priority) {
 			out = append(out, t)
 		}
 	}
@@ -0,0 +1,180 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	gengo "k8s.io/gengo/v2"
+	"k8s.io/gengo/v2/types"
+)
+
+// validationMarkerPrefixes are the prefixes of the validation markers, in
+// order of priority. Markers of our own take priority over kubebuilder markers.
+var validationMarkerPrefixes = []string{
+	"reference-gen:validation:",
+	"kubebuilder:validation:",
+}
+
+// constraintKinds are the supported validation markers, in the order they are
+// documented, along with the kind of value they take.
+var constraintKinds = []struct {
+	name  string
+	value string
+}{
+	{"Minimum", "number"},
+	{"ExclusiveMinimum", "bool"},
+	{"Maximum", "number"},
+	{"ExclusiveMaximum", "bool"},
+	{"MultipleOf", "number"},
+	{"MinLength", "integer"},
+	{"MaxLength", "integer"},
+	{"Pattern", "string"},
+	{"Format", "string"},
+	{"MinItems", "integer"},
+	{"MaxItems", "integer"},
+	{"UniqueItems", "bool"},
+	{"Enum", "list"},
+}
+
+// constraint is a validation rule of a member, set by a validation marker such
+// as +kubebuilder:validation:Minimum=1.
+type constraint struct {
+	// Name is the name of the validation marker, such as Minimum.
+	Name string
+	// Value is the value of the marker.
+	Value string
+}
+
+// memberConstraints returns the validation rules of the member.
+func memberConstraints(m types.Member) []constraint {
+	tags := gengo.ExtractCommentTags("+", m.CommentLines)
+
+	var out []constraint
+	for _, kind := range constraintKinds {
+		for _, prefix := range validationMarkerPrefixes {
+			if values, ok := tags[prefix+kind.name]; ok {
+				// There should only be one entry
+				out = append(out, constraint{Name: kind.name, Value: values[0]})
+				break
+			}
+		}
+	}
+	return out
+}
+
+// checkConstraints checks that the values of the validation markers of the
+// members of the types can be parsed.
+func checkConstraints(typeList []*types.Type, priority []string) error {
+	for _, t := range typeList {
+		for _, m := range visibleMembers(t.Members, priority) {
+			for _, c := range memberConstraints(m) {
+				if err := c.check(); err != nil {
+					return fmt.Errorf("invalid validation marker on %s.%s: %v", t.Name, m.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// check checks that the value of the constraint can be parsed.
+func (c constraint) check() error {
+	var err error
+	switch c.kind() {
+	case "number":
+		_, err = strconv.ParseFloat(c.Value, 64)
+	case "integer":
+		_, err = strconv.Atoi(c.Value)
+	case "bool":
+		_, err = strconv.ParseBool(c.Value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s must be a %s, got %q", c.Name, c.kind(), c.Value)
+	}
+	return nil
+}
+
+func (c constraint) kind() string {
+	for _, kind := range constraintKinds {
+		if kind.name == c.Name {
+			return kind.value
+		}
+	}
+	return ""
+}
+
+// showConstraintsColumnFunc constructs a showConstraintsColumn function for the template.
+// The column is shown for every type when any documented member has a
+// constraint, so that the tables within a document are consistent.
+func showConstraintsColumnFunc(typeList []*types.Type, priority []string) func() bool {
+	show := false
+	for _, t := range visibleTypes(typeList) {
+		for _, m := range visibleMembers(t.Members, priority) {
+			if len(memberConstraints(m)) > 0 {
+				show = true
+			}
+		}
+	}
+	return func() bool {
+		return show
+	}
+}
+
+// applyConstraints adds the validation rules to the schema of the member.
+// The constraints must have been checked with checkConstraints.
+func applyConstraints(s *jsonSchema, constraints []constraint) {
+	var exclusiveMinimum, exclusiveMaximum bool
+	for _, c := range constraints {
+		switch c.Name {
+		case "Minimum":
+			s.Minimum = parseFloat(c.Value)
+		case "Maximum":
+			s.Maximum = parseFloat(c.Value)
+		case "ExclusiveMinimum":
+			exclusiveMinimum, _ = strconv.ParseBool(c.Value)
+		case "ExclusiveMaximum":
+			exclusiveMaximum, _ = strconv.ParseBool(c.Value)
+		case "MultipleOf":
+			s.MultipleOf = parseFloat(c.Value)
+		case "MinLength":
+			s.MinLength = parseInt(c.Value)
+		case "MaxLength":
+			s.MaxLength = parseInt(c.Value)
+		case "Pattern":
+			s.Pattern = c.Value
+		case "Format":
+			s.Format = c.Value
+		case "MinItems":
+			s.MinItems = parseInt(c.Value)
+		case "MaxItems":
+			s.MaxItems = parseInt(c.Value)
+		case "UniqueItems":
+			s.UniqueItems, _ = strconv.ParseBool(c.Value)
+		case "Enum":
+			s.Enum = nil
+			for _, v := range strings.Split(c.Value, ";") {
+				s.Enum = append(s.Enum, schemaDefault(v, s))
+			}
+		}
+	}
+
+	// Kubebuilder markers make the bounds exclusive with a flag, as in
+	// OpenAPI v3.0, whereas JSON Schema uses separate keywords.
+	if exclusiveMinimum && s.Minimum != nil {
+		s.ExclusiveMinimum, s.Minimum = s.Minimum, nil
+	}
+	if exclusiveMaximum && s.Maximum != nil {
+		s.ExclusiveMaximum, s.Maximum = s.Maximum, nil
+	}
+}
+
+func parseFloat(s string) *float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return &v
+}
+
+func parseInt(s string) *int {
+	v, _ := strconv.Atoi(s)
+	return &v
+}
@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"strings"
+
+	gengo "k8s.io/gengo/v2"
+	"k8s.io/gengo/v2/types"
+)
+
+const (
+	hiddenMarker   = "reference-gen:hidden"
+	audienceMarker = "reference-gen:audience"
+)
+
+// isHidden determines if the comments hide the type or member with a
+// +reference-gen:hidden marker.
+func isHidden(commentLines []string) bool {
+	tags := gengo.ExtractCommentTags("+", commentLines)
+	_, ok := tags[hiddenMarker]
+	return ok
+}
+
+// visibility determines which types and members are documented, based on
+// their markers.
+// Types and members are documented when they are not hidden, are at least as
+// stable as the minimum stability, and are either for everyone or for one of
+// the audiences being documented.
+type visibility struct {
+	minStability string
+	audiences    stringSet
+}
+
+// includes determines if the type or member with the comments is documented.
+func (v visibility) includes(commentLines []string) bool {
+	if isHidden(commentLines) {
+		return false
+	}
+	if stabilityLevels[stabilityOf(commentLines)] < stabilityLevels[v.minStability] {
+		return false
+	}
+
+	tags := gengo.ExtractCommentTags("+", commentLines)
+	values, ok := tags[audienceMarker]
+	if !ok {
+		// Types and members without an audience are for everyone.
+		return true
+	}
+	// There should only be one entry
+	for _, audience := range strings.Split(values[0], ",") {
+		if v.audiences.has(strings.TrimSpace(audience)) {
+			return true
+		}
+	}
+	return false
+}
+
+// includesTypes determines if all of the types are documented.
+func (v visibility) includesTypes(typs []*types.Type) bool {
+	for _, t := range typs {
+		if !v.includes(t.CommentLines) {
+			return false
+		}
+	}
+	return true
+}
+
+// prune removes the types and members that are not documented, along with any
+// types that are only reachable through them. Members are removed from the
+// types, so that they are not rendered.
+// The types that had visible members removed are returned as partial, as
+// their configuration may still set the members.
+// Members are named and embedded by the struct tags of the priority.
+func (v visibility) prune(allTypes map[string]*types.Type, priority []string) (map[string]*types.Type, typeSet) {
+	// Types that are not referenced by other types are the roots of the
+	// configuration, as are types only reachable from each other.
+	roots := make(typeSet)
+	for t, refs := range findTypeReferences(allTypes, priority) {
+		if !referencedByOthers(t, refs) {
+			roots.add(t)
+		}
+	}
+	reachable := reachableTypes(roots, func(*types.Type) bool { return true }, priority)
+	for _, t := range allTypes {
+		if !reachable.has(t) {
+			roots.add(t)
+		}
+	}
+
+	// Members are only documented when both the member and every type within
+	// its type, such as the elements of a slice or map, are.
+	partial := make(typeSet)
+	for _, t := range allTypes {
+		var members []types.Member
+		for _, m := range t.Members {
+			if v.includes(m.CommentLines) && v.includesTypes(referencedTypes(m.Type)) {
+				members = append(members, m)
+			} else if marshalledMember(m, priority) {
+				partial.add(t)
+			}
+		}
+		t.Members = members
+	}
+
+	reachable = reachableTypes(roots, func(t *types.Type) bool {
+		return v.includes(t.CommentLines)
+	}, priority)
+
+	out := make(map[string]*types.Type)
+	for name, t := range allTypes {
+		if reachable.has(t) {
+			out[name] = t
+		}
+	}
+	return out, partial
+}
+
+// referencedByOthers determines if any type other than the type itself
+// references it.
+func referencedByOthers(t *types.Type, refs []*types.Type) bool {
+	for _, ref := range refs {
+		if ref != t {
+			return true
+		}
+	}
+	return false
+}
+
+// reachableTypes returns the included types reachable from the roots through
+// the visible members and underlying types of included types.
+func reachableTypes(roots typeSet, include func(t *types.Type) bool, priority []string) typeSet {
+	reachable := make(typeSet)
+	var visit func(t *types.Type)
+	visit = func(t *types.Type) {
+		if reachable.has(t) || !include(t) {
+			return
+		}
+		reachable.add(t)
+		for _, m := range t.Members {
+			if !hideMember(m, priority) {
+				for _, ref := range referencedTypes(m.Type) {
+					visit(ref)
+				}
+			}
+		}
+		if t.Underlying != nil {
+			for _, ref := range referencedTypes(t.Underlying) {
+				visit(ref)
+			}
+		}
+	}
+	for t := range roots {
+		visit(t)
+	}
+	return reachable
+}